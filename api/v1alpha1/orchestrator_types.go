@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -36,6 +37,10 @@ type OrchestratorSpec struct {
 	OrchestratorPlatform OrchestratorPlatform `json:"orchestrator,omitempty"`
 	Tekton               Tekton               `json:"tekton,omitempty"`
 	ArgoCd               ArgoCD               `json:"argocd,omitempty"`
+	AirGapped            AirGapped            `json:"airGapped,omitempty"`
+	DryRun               bool                 `json:"dryRun,omitempty"`
+	Paused               bool                 `json:"paused,omitempty"`
+	Workflows            []Workflow           `json:"workflows,omitempty"`
 }
 
 type Subscription struct {
@@ -44,6 +49,7 @@ type Subscription struct {
 	InstallPlanApproval string `json:"installPlanApproval,omitempty"`
 	Name                string `json:"name,omitempty"`
 	SourceName          string `json:"sourceName,omitempty"`
+	SourceNamespace     string `json:"sourceNamespace,omitempty"`
 	StartingCSV         string `json:"startingCSV,omitempty"`
 	TargetNamespace     string `json:"targetNamespace,omitempty"`
 }
@@ -51,41 +57,95 @@ type Subscription struct {
 type SonataFlowOperator struct {
 	IsReleaseCandidate bool         `json:"isReleaseCandidate,omitempty"`
 	Enabled            bool         `json:"enabled,omitempty"`
+	Paused             bool         `json:"paused,omitempty"`
 	Subscription       Subscription `json:"subscription,omitempty"`
 }
 
 type ServerlessOperator struct {
 	Enabled      bool         `json:"enabled,omitempty"`
+	Paused       bool         `json:"paused,omitempty"`
 	Subscription Subscription `json:"subscription,omitempty"`
+	Brokers      []Broker     `json:"brokers,omitempty"`
+}
+
+// +kubebuilder:validation:Enum={"InMemory","Kafka"}
+type BrokerType string
+
+var (
+	InMemoryBroker BrokerType = "InMemory"
+	KafkaBroker    BrokerType = "Kafka"
+)
+
+// Broker is a Knative Eventing broker created in the SonataFlow namespace.
+// The broker is backed by in-memory channels unless its type is Kafka.
+type Broker struct {
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name     string          `json:"name"`
+	Type     BrokerType      `json:"type,omitempty"`
+	Kafka    *KafkaChannel   `json:"kafka,omitempty"`
+	Delivery *BrokerDelivery `json:"delivery,omitempty"`
+	Triggers []BrokerTrigger `json:"triggers,omitempty"`
+}
+
+type KafkaChannel struct {
+	BootstrapServers  string `json:"bootstrapServers"`
+	NumPartitions     int32  `json:"numPartitions,omitempty"`
+	ReplicationFactor int32  `json:"replicationFactor,omitempty"`
+	AuthSecret        string `json:"authSecret,omitempty"`
+}
+
+// BrokerTrigger delivers the events of the broker to a workflow, through the
+// Service of the workflow in the SonataFlow namespace. The events are
+// filtered by their CloudEvents attributes, such as their type.
+type BrokerTrigger struct {
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name     string            `json:"name"`
+	Workflow string            `json:"workflow"`
+	Filter   map[string]string `json:"filter,omitempty"`
+}
+
+type BrokerDelivery struct {
+	Retry *int32 `json:"retry,omitempty"`
+	// +kubebuilder:validation:Enum={"linear","exponential"}
+	BackoffPolicy string `json:"backoffPolicy,omitempty"`
+	BackoffDelay  string `json:"backoffDelay,omitempty"`
+}
+
+// SecretKeySelector references the key of a secret holding a credential. The
+// key is injected into Backstage as an environment variable of the same name.
+// The secret defaults to the secretRef name.
+type SecretKeySelector struct {
+	Name string `json:"name,omitempty"`
+	Key  string `json:"key,omitempty"`
 }
 
 type BackstageSecret struct {
-	BackendSecret string `json:"backendSecret,omitempty"`
+	BackendSecret SecretKeySelector `json:"backendSecret,omitempty"`
 }
 
 type ClusterTokenUrl struct {
-	ClusterToken string `json:"clusterToken,omitempty"`
-	ClusterUrl   string `json:"clusterUrl,omitempty"`
+	ClusterToken SecretKeySelector `json:"clusterToken,omitempty"`
+	ClusterUrl   SecretKeySelector `json:"clusterUrl,omitempty"`
 }
 
 type GithubBS struct {
-	Token        string `json:"token,omitempty"`
-	ClientID     string `json:"clientId,omitempty"`
-	ClientSecret string `json:"clientSecret,omitempty"`
+	Token        SecretKeySelector `json:"token,omitempty"`
+	ClientID     SecretKeySelector `json:"clientId,omitempty"`
+	ClientSecret SecretKeySelector `json:"clientSecret,omitempty"`
 }
 
 type ArgoCDBS struct {
-	Enabled   bool   `json:"enabled,omitempty"`
-	Namespace string `json:"namespace,omitempty"`
-	Url       string `json:"url,omitempty"`
-	Username  string `json:"username,omitempty"`
-	Password  string `json:"password,omitempty"`
+	Enabled   bool              `json:"enabled,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Url       SecretKeySelector `json:"url,omitempty"`
+	Username  SecretKeySelector `json:"username,omitempty"`
+	Password  SecretKeySelector `json:"password,omitempty"`
 }
 
 type NotificationEmailBS struct {
-	Hostname string `json:"hostname,omitempty"`
-	Username string `json:"username,omitempty"`
-	Password string `json:"password,omitempty"`
+	Hostname SecretKeySelector `json:"hostname,omitempty"`
+	Username SecretKeySelector `json:"username,omitempty"`
+	Password SecretKeySelector `json:"password,omitempty"`
 }
 
 type SecretRefBS struct {
@@ -100,10 +160,89 @@ type SecretRefBS struct {
 type RHDHOperator struct {
 	IsReleaseCandidate  bool         `json:"isReleaseCandidate,omitempty"`
 	Enabled             bool         `json:"enabled,omitempty"`
+	Paused              bool         `json:"paused,omitempty"`
 	EnableGuestProvider bool         `json:"enableGuestProvider,omitempty"`
 	CatalogBranch       string       `json:"catalogBranch,omitempty"`
 	Subscription        Subscription `json:"subscription,omitempty"`
 	SecretRef           SecretRefBS  `json:"secretRef,omitempty"`
+	Auth                AuthConfig   `json:"auth,omitempty"`
+	Integrations        Integrations `json:"integrations,omitempty"`
+	Catalog             Catalog      `json:"catalog,omitempty"`
+}
+
+type Catalog struct {
+	Rules                   []CatalogRule     `json:"rules,omitempty"`
+	Locations               []CatalogLocation `json:"locations,omitempty"`
+	ExcludeDefaultLocations bool              `json:"excludeDefaultLocations,omitempty"`
+	// TemplatesConfigMap is a configmap of the Backstage namespace holding the
+	// workflow templates, for clusters without access to the
+	// workflow-software-templates repository. It is mounted into Backstage and
+	// its YAML files replace the default locations.
+	TemplatesConfigMap string `json:"templatesConfigMap,omitempty"`
+}
+
+type CatalogRule struct {
+	Allow []string `json:"allow"`
+}
+
+// +kubebuilder:validation:Enum={"url","file"}
+type CatalogLocationType string
+
+var (
+	URLCatalogLocation  CatalogLocationType = "url"
+	FileCatalogLocation CatalogLocationType = "file"
+)
+
+type CatalogLocation struct {
+	Type   CatalogLocationType `json:"type"`
+	Target string              `json:"target"`
+	Rules  []CatalogRule       `json:"rules,omitempty"`
+}
+
+type Integrations struct {
+	Github          []GitIntegration            `json:"github,omitempty"`
+	Gitlab          []GitIntegration            `json:"gitlab,omitempty"`
+	BitbucketCloud  []BitbucketCloudIntegration `json:"bitbucketCloud,omitempty"`
+	BitbucketServer []GitIntegration            `json:"bitbucketServer,omitempty"`
+	Azure           []GitIntegration            `json:"azure,omitempty"`
+}
+
+type GitIntegration struct {
+	Host       string            `json:"host,omitempty"`
+	ApiBaseUrl string            `json:"apiBaseUrl,omitempty"`
+	Token      SecretKeySelector `json:"token,omitempty"`
+}
+
+type BitbucketCloudIntegration struct {
+	Username    SecretKeySelector `json:"username,omitempty"`
+	AppPassword SecretKeySelector `json:"appPassword,omitempty"`
+}
+
+// +kubebuilder:validation:Enum={"oidc","github","gitlab","microsoft","guest"}
+type AuthProviderType string
+
+var (
+	OIDCAuthProvider      AuthProviderType = "oidc"
+	GithubAuthProvider    AuthProviderType = "github"
+	GitlabAuthProvider    AuthProviderType = "gitlab"
+	MicrosoftAuthProvider AuthProviderType = "microsoft"
+	GuestAuthProvider     AuthProviderType = "guest"
+)
+
+type AuthConfig struct {
+	Environment string         `json:"environment,omitempty"`
+	SignInPage  string         `json:"signInPage,omitempty"`
+	Providers   []AuthProvider `json:"providers,omitempty"`
+}
+
+type AuthProvider struct {
+	Type           AuthProviderType  `json:"type"`
+	ClientID       SecretKeySelector `json:"clientId,omitempty"`
+	ClientSecret   SecretKeySelector `json:"clientSecret,omitempty"`
+	MetadataUrl    SecretKeySelector `json:"metadataUrl,omitempty"`
+	TenantID       SecretKeySelector `json:"tenantId,omitempty"`
+	Audience       string            `json:"audience,omitempty"`
+	SignInResolver string            `json:"signInResolver,omitempty"`
 }
 
 type PluginDetails struct {
@@ -123,6 +262,33 @@ type RHDHPlugins struct {
 	Scope               string                   `json:"scope,omitempty"`
 	Plugins             map[string]PluginDetails `json:"plugins,omitempty"`
 	NotificationsConfig NotificationConfig       `json:"notificationsConfig,omitempty"`
+	VerifyIntegrity     bool                     `json:"verifyIntegrity,omitempty"`
+	NpmAuthSecret       NpmAuthSecret            `json:"npmAuthSecret,omitempty"`
+	ScopedRegistries    []ScopedNpmRegistry      `json:"scopedRegistries,omitempty"`
+	NpmCABundle         NpmCABundle              `json:"npmCABundle,omitempty"`
+	NpmProxy            NpmProxy                 `json:"npmProxy,omitempty"`
+}
+
+type NpmAuthSecret struct {
+	Name     string `json:"name,omitempty"`
+	TokenKey string `json:"tokenKey,omitempty"`
+}
+
+type ScopedNpmRegistry struct {
+	Scope    string `json:"scope,omitempty"`
+	Registry string `json:"registry,omitempty"`
+	TokenKey string `json:"tokenKey,omitempty"`
+}
+
+type NpmCABundle struct {
+	ConfigMapName string `json:"configMapName,omitempty"`
+	Key           string `json:"key,omitempty"`
+}
+
+type NpmProxy struct {
+	HttpProxy  string `json:"httpProxy,omitempty"`
+	HttpsProxy string `json:"httpsProxy,omitempty"`
+	NoProxy    string `json:"noProxy,omitempty"`
 }
 
 type Postgres struct {
@@ -130,6 +296,7 @@ type Postgres struct {
 	ServiceNameSpace string             `json:"serviceNamespace,omitempty"`
 	AuthSecret       PostgresAuthSecret `json:"authSecret,omitempty"`
 	DatabaseName     string             `json:"database,omitempty"`
+	ClientImage      string             `json:"clientImage,omitempty"`
 }
 
 type PostgresAuthSecret struct {
@@ -138,23 +305,100 @@ type PostgresAuthSecret struct {
 	PasswordKey string `json:"passwordKey,omitempty"`
 }
 
+type Workflow struct {
+	// Name of the workflow. The names derived from it must fit in 63
+	// characters: the Job setting up its database, with the "-db-setup"
+	// suffix, and its Postgres role, with the "wf_" prefix.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=54
+	Name       string                   `json:"name"`
+	ConfigMap  *WorkflowConfigMapSource `json:"configMap,omitempty"`
+	Git        *WorkflowGitSource       `json:"git,omitempty"`
+	Image      string                   `json:"image,omitempty"`
+	Properties map[string]string        `json:"properties,omitempty"`
+	SecretRefs []string                 `json:"secretRefs,omitempty"`
+}
+
+type WorkflowConfigMapSource struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+}
+
+type WorkflowGitSource struct {
+	Repository string `json:"repository"`
+	Ref        string `json:"ref,omitempty"`
+	Path       string `json:"path"`
+}
+
 type OrchestratorPlatform struct {
 	Namespace          string             `json:"namespace,omitempty"`
 	SonataFlowPlatform SonataFlowPlatform `json:"sonataFlowPlatform,omitempty"`
 }
 
 type SonataFlowPlatform struct {
-	Resources Resource `json:"resources,omitempty"`
+	Resources  corev1.ResourceRequirements `json:"resources,omitempty"`
+	Build      PlatformBuild               `json:"build,omitempty"`
+	DevMode    PlatformDevMode             `json:"devMode,omitempty"`
+	Eventing   PlatformEventing            `json:"eventing,omitempty"`
+	DataIndex  PlatformService             `json:"dataIndex,omitempty"`
+	JobService PlatformService             `json:"jobService,omitempty"`
+}
+
+// PlatformBuild configures the builds of the workflow images on the
+// platform.
+type PlatformBuild struct {
+	Registry  PlatformBuildRegistry `json:"registry,omitempty"`
+	BaseImage string                `json:"baseImage,omitempty"`
+	Arguments []string              `json:"arguments,omitempty"`
+	Envs      []corev1.EnvVar       `json:"envs,omitempty"`
+	// +kubebuilder:validation:Enum=platform;operator
+	Strategy        string            `json:"strategy,omitempty"`
+	StrategyOptions map[string]string `json:"strategyOptions,omitempty"`
+	Timeout         *metav1.Duration  `json:"timeout,omitempty"`
+}
+
+type PlatformBuildRegistry struct {
+	Address      string `json:"address,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Secret       string `json:"secret,omitempty"`
+	CA           string `json:"ca,omitempty"`
+	Insecure     bool   `json:"insecure,omitempty"`
+}
+
+type PlatformDevMode struct {
+	BaseImage string `json:"baseImage,omitempty"`
+}
+
+type PlatformEventing struct {
+	Broker *BrokerReference `json:"broker,omitempty"`
+}
+
+// BrokerReference references a Knative Eventing broker, in the SonataFlow
+// namespace unless the namespace is set.
+type BrokerReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
 }
 
-type Resource struct {
-	Requests MemoryCpu `json:"requests,omitempty"`
-	Limits   MemoryCpu `json:"limits,omitempty"`
+// PlatformService configures a service of the SonataFlow platform. The
+// service is enabled unless Enabled is false, and persists in the PostgreSQL
+// database of the spec unless another database is set.
+type PlatformService struct {
+	Enabled     *bool                       `json:"enabled,omitempty"`
+	Database    string                      `json:"database,omitempty"`
+	Schema      string                      `json:"schema,omitempty"`
+	Replicas    *int32                      `json:"replicas,omitempty"`
+	Resources   corev1.ResourceRequirements `json:"resources,omitempty"`
+	PodTemplate PlatformServicePodTemplate  `json:"podTemplate,omitempty"`
 }
 
-type MemoryCpu struct {
-	Memory string `json:"memory,omitempty"`
-	Cpu    string `json:"cpu,omitempty"`
+type PlatformServicePodTemplate struct {
+	Image              string              `json:"image,omitempty"`
+	Env                []corev1.EnvVar     `json:"env,omitempty"`
+	NodeSelector       map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations        []corev1.Toleration `json:"tolerations,omitempty"`
+	Affinity           *corev1.Affinity    `json:"affinity,omitempty"`
+	ServiceAccountName string              `json:"serviceAccountName,omitempty"`
 }
 
 type Tekton struct {
@@ -166,6 +410,23 @@ type ArgoCD struct {
 	Namespace bool `json:"namespace,omitempty"`
 }
 
+type AirGapped struct {
+	Enabled        bool                  `json:"enabled,omitempty"`
+	MirrorRegistry string                `json:"mirrorRegistry,omitempty"`
+	CatalogSource  MirroredCatalogSource `json:"catalogSource,omitempty"`
+	NpmRegistry    string                `json:"npmRegistry,omitempty"`
+	// GitMirror is the base URL of a Git server mirroring the repositories
+	// under their host and path: https://github.com/org/repo is served at
+	// <gitMirror>/github.com/org/repo.
+	GitMirror    string   `json:"gitMirror,omitempty"`
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+}
+
+type MirroredCatalogSource struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
 type OrchestratorPhase string
 
 // OrchestratorStatus defines the observed state of Orchestrator
@@ -173,8 +434,24 @@ type OrchestratorStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the spec last reconciled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 	// +kubebuilder:validation:Enum={"Running","Completed", "Failed"}
 	Phase OrchestratorPhase `json:"phase,omitempty" protobuf:"bytes,1,opt,casttype=OrchestratorPhase"`
+	// ExternalReferences lists the references to external endpoints left when running air-gapped
+	ExternalReferences []string `json:"externalReferences,omitempty"`
+	// Plan lists the actions the operator would perform when running in dry run
+	Plan []PlannedAction `json:"plan,omitempty"`
+}
+
+// PlannedAction is a write the operator would perform on a resource
+type PlannedAction struct {
+	Component string `json:"component"`
+	// +kubebuilder:validation:Enum={"Create","Update","Delete"}
+	Action    string `json:"action"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
 }
 
 //+kubebuilder:object:root=true
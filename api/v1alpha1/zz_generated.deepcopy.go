@@ -21,10 +21,32 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AirGapped) DeepCopyInto(out *AirGapped) {
+	*out = *in
+	out.CatalogSource = in.CatalogSource
+	if in.AllowedHosts != nil {
+		in, out := &in.AllowedHosts, &out.AllowedHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AirGapped.
+func (in *AirGapped) DeepCopy() *AirGapped {
+	if in == nil {
+		return nil
+	}
+	out := new(AirGapped)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ArgoCD) DeepCopyInto(out *ArgoCD) {
 	*out = *in
@@ -43,6 +65,9 @@ func (in *ArgoCD) DeepCopy() *ArgoCD {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ArgoCDBS) DeepCopyInto(out *ArgoCDBS) {
 	*out = *in
+	out.Url = in.Url
+	out.Username = in.Username
+	out.Password = in.Password
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArgoCDBS.
@@ -55,9 +80,49 @@ func (in *ArgoCDBS) DeepCopy() *ArgoCDBS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthConfig) DeepCopyInto(out *AuthConfig) {
+	*out = *in
+	if in.Providers != nil {
+		in, out := &in.Providers, &out.Providers
+		*out = make([]AuthProvider, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthConfig.
+func (in *AuthConfig) DeepCopy() *AuthConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthProvider) DeepCopyInto(out *AuthProvider) {
+	*out = *in
+	out.ClientID = in.ClientID
+	out.ClientSecret = in.ClientSecret
+	out.MetadataUrl = in.MetadataUrl
+	out.TenantID = in.TenantID
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthProvider.
+func (in *AuthProvider) DeepCopy() *AuthProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackstageSecret) DeepCopyInto(out *BackstageSecret) {
 	*out = *in
+	out.BackendSecret = in.BackendSecret
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackstageSecret.
@@ -70,9 +135,188 @@ func (in *BackstageSecret) DeepCopy() *BackstageSecret {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BitbucketCloudIntegration) DeepCopyInto(out *BitbucketCloudIntegration) {
+	*out = *in
+	out.Username = in.Username
+	out.AppPassword = in.AppPassword
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BitbucketCloudIntegration.
+func (in *BitbucketCloudIntegration) DeepCopy() *BitbucketCloudIntegration {
+	if in == nil {
+		return nil
+	}
+	out := new(BitbucketCloudIntegration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Broker) DeepCopyInto(out *Broker) {
+	*out = *in
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(KafkaChannel)
+		**out = **in
+	}
+	if in.Delivery != nil {
+		in, out := &in.Delivery, &out.Delivery
+		*out = new(BrokerDelivery)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Triggers != nil {
+		in, out := &in.Triggers, &out.Triggers
+		*out = make([]BrokerTrigger, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Broker.
+func (in *Broker) DeepCopy() *Broker {
+	if in == nil {
+		return nil
+	}
+	out := new(Broker)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BrokerDelivery) DeepCopyInto(out *BrokerDelivery) {
+	*out = *in
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerDelivery.
+func (in *BrokerDelivery) DeepCopy() *BrokerDelivery {
+	if in == nil {
+		return nil
+	}
+	out := new(BrokerDelivery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BrokerReference) DeepCopyInto(out *BrokerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerReference.
+func (in *BrokerReference) DeepCopy() *BrokerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(BrokerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BrokerTrigger) DeepCopyInto(out *BrokerTrigger) {
+	*out = *in
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerTrigger.
+func (in *BrokerTrigger) DeepCopy() *BrokerTrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(BrokerTrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Catalog) DeepCopyInto(out *Catalog) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]CatalogRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Locations != nil {
+		in, out := &in.Locations, &out.Locations
+		*out = make([]CatalogLocation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Catalog.
+func (in *Catalog) DeepCopy() *Catalog {
+	if in == nil {
+		return nil
+	}
+	out := new(Catalog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogLocation) DeepCopyInto(out *CatalogLocation) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]CatalogRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogLocation.
+func (in *CatalogLocation) DeepCopy() *CatalogLocation {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogLocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogRule) DeepCopyInto(out *CatalogRule) {
+	*out = *in
+	if in.Allow != nil {
+		in, out := &in.Allow, &out.Allow
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogRule.
+func (in *CatalogRule) DeepCopy() *CatalogRule {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterTokenUrl) DeepCopyInto(out *ClusterTokenUrl) {
 	*out = *in
+	out.ClusterToken = in.ClusterToken
+	out.ClusterUrl = in.ClusterUrl
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTokenUrl.
@@ -85,9 +329,28 @@ func (in *ClusterTokenUrl) DeepCopy() *ClusterTokenUrl {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitIntegration) DeepCopyInto(out *GitIntegration) {
+	*out = *in
+	out.Token = in.Token
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitIntegration.
+func (in *GitIntegration) DeepCopy() *GitIntegration {
+	if in == nil {
+		return nil
+	}
+	out := new(GitIntegration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubBS) DeepCopyInto(out *GithubBS) {
 	*out = *in
+	out.Token = in.Token
+	out.ClientID = in.ClientID
+	out.ClientSecret = in.ClientSecret
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubBS.
@@ -101,16 +364,71 @@ func (in *GithubBS) DeepCopy() *GithubBS {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MemoryCpu) DeepCopyInto(out *MemoryCpu) {
+func (in *Integrations) DeepCopyInto(out *Integrations) {
+	*out = *in
+	if in.Github != nil {
+		in, out := &in.Github, &out.Github
+		*out = make([]GitIntegration, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gitlab != nil {
+		in, out := &in.Gitlab, &out.Gitlab
+		*out = make([]GitIntegration, len(*in))
+		copy(*out, *in)
+	}
+	if in.BitbucketCloud != nil {
+		in, out := &in.BitbucketCloud, &out.BitbucketCloud
+		*out = make([]BitbucketCloudIntegration, len(*in))
+		copy(*out, *in)
+	}
+	if in.BitbucketServer != nil {
+		in, out := &in.BitbucketServer, &out.BitbucketServer
+		*out = make([]GitIntegration, len(*in))
+		copy(*out, *in)
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = make([]GitIntegration, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Integrations.
+func (in *Integrations) DeepCopy() *Integrations {
+	if in == nil {
+		return nil
+	}
+	out := new(Integrations)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaChannel) DeepCopyInto(out *KafkaChannel) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaChannel.
+func (in *KafkaChannel) DeepCopy() *KafkaChannel {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaChannel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirroredCatalogSource) DeepCopyInto(out *MirroredCatalogSource) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemoryCpu.
-func (in *MemoryCpu) DeepCopy() *MemoryCpu {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirroredCatalogSource.
+func (in *MirroredCatalogSource) DeepCopy() *MirroredCatalogSource {
 	if in == nil {
 		return nil
 	}
-	out := new(MemoryCpu)
+	out := new(MirroredCatalogSource)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -133,6 +451,9 @@ func (in *NotificationConfig) DeepCopy() *NotificationConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NotificationEmailBS) DeepCopyInto(out *NotificationEmailBS) {
 	*out = *in
+	out.Hostname = in.Hostname
+	out.Username = in.Username
+	out.Password = in.Password
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationEmailBS.
@@ -145,6 +466,51 @@ func (in *NotificationEmailBS) DeepCopy() *NotificationEmailBS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NpmAuthSecret) DeepCopyInto(out *NpmAuthSecret) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NpmAuthSecret.
+func (in *NpmAuthSecret) DeepCopy() *NpmAuthSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(NpmAuthSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NpmCABundle) DeepCopyInto(out *NpmCABundle) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NpmCABundle.
+func (in *NpmCABundle) DeepCopy() *NpmCABundle {
+	if in == nil {
+		return nil
+	}
+	out := new(NpmCABundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NpmProxy) DeepCopyInto(out *NpmProxy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NpmProxy.
+func (in *NpmProxy) DeepCopy() *NpmProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(NpmProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Orchestrator) DeepCopyInto(out *Orchestrator) {
 	*out = *in
@@ -207,7 +573,7 @@ func (in *OrchestratorList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OrchestratorPlatform) DeepCopyInto(out *OrchestratorPlatform) {
 	*out = *in
-	out.SonataFlowPlatform = in.SonataFlowPlatform
+	in.SonataFlowPlatform.DeepCopyInto(&out.SonataFlowPlatform)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrchestratorPlatform.
@@ -224,13 +590,21 @@ func (in *OrchestratorPlatform) DeepCopy() *OrchestratorPlatform {
 func (in *OrchestratorSpec) DeepCopyInto(out *OrchestratorSpec) {
 	*out = *in
 	out.SonataFlowOperator = in.SonataFlowOperator
-	out.ServerlessOperator = in.ServerlessOperator
-	out.RhdhOperator = in.RhdhOperator
+	in.ServerlessOperator.DeepCopyInto(&out.ServerlessOperator)
+	in.RhdhOperator.DeepCopyInto(&out.RhdhOperator)
 	in.RhdhPlugins.DeepCopyInto(&out.RhdhPlugins)
 	out.PostgresDB = in.PostgresDB
-	out.OrchestratorPlatform = in.OrchestratorPlatform
+	in.OrchestratorPlatform.DeepCopyInto(&out.OrchestratorPlatform)
 	out.Tekton = in.Tekton
 	out.ArgoCd = in.ArgoCd
+	in.AirGapped.DeepCopyInto(&out.AirGapped)
+	if in.Workflows != nil {
+		in, out := &in.Workflows, &out.Workflows
+		*out = make([]Workflow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrchestratorSpec.
@@ -248,11 +622,21 @@ func (in *OrchestratorStatus) DeepCopyInto(out *OrchestratorStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ExternalReferences != nil {
+		in, out := &in.ExternalReferences, &out.ExternalReferences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = make([]PlannedAction, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrchestratorStatus.
@@ -265,6 +649,179 @@ func (in *OrchestratorStatus) DeepCopy() *OrchestratorStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlannedAction) DeepCopyInto(out *PlannedAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlannedAction.
+func (in *PlannedAction) DeepCopy() *PlannedAction {
+	if in == nil {
+		return nil
+	}
+	out := new(PlannedAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformBuild) DeepCopyInto(out *PlatformBuild) {
+	*out = *in
+	out.Registry = in.Registry
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Envs != nil {
+		in, out := &in.Envs, &out.Envs
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StrategyOptions != nil {
+		in, out := &in.StrategyOptions, &out.StrategyOptions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformBuild.
+func (in *PlatformBuild) DeepCopy() *PlatformBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformBuildRegistry) DeepCopyInto(out *PlatformBuildRegistry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformBuildRegistry.
+func (in *PlatformBuildRegistry) DeepCopy() *PlatformBuildRegistry {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformBuildRegistry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformDevMode) DeepCopyInto(out *PlatformDevMode) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformDevMode.
+func (in *PlatformDevMode) DeepCopy() *PlatformDevMode {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformDevMode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformEventing) DeepCopyInto(out *PlatformEventing) {
+	*out = *in
+	if in.Broker != nil {
+		in, out := &in.Broker, &out.Broker
+		*out = new(BrokerReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformEventing.
+func (in *PlatformEventing) DeepCopy() *PlatformEventing {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformEventing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformService) DeepCopyInto(out *PlatformService) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.PodTemplate.DeepCopyInto(&out.PodTemplate)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformService.
+func (in *PlatformService) DeepCopy() *PlatformService {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformServicePodTemplate) DeepCopyInto(out *PlatformServicePodTemplate) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformServicePodTemplate.
+func (in *PlatformServicePodTemplate) DeepCopy() *PlatformServicePodTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformServicePodTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PluginDetails) DeepCopyInto(out *PluginDetails) {
 	*out = *in
@@ -316,6 +873,9 @@ func (in *RHDHOperator) DeepCopyInto(out *RHDHOperator) {
 	*out = *in
 	out.Subscription = in.Subscription
 	out.SecretRef = in.SecretRef
+	in.Auth.DeepCopyInto(&out.Auth)
+	in.Integrations.DeepCopyInto(&out.Integrations)
+	in.Catalog.DeepCopyInto(&out.Catalog)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHDHOperator.
@@ -339,6 +899,14 @@ func (in *RHDHPlugins) DeepCopyInto(out *RHDHPlugins) {
 		}
 	}
 	out.NotificationsConfig = in.NotificationsConfig
+	out.NpmAuthSecret = in.NpmAuthSecret
+	if in.ScopedRegistries != nil {
+		in, out := &in.ScopedRegistries, &out.ScopedRegistries
+		*out = make([]ScopedNpmRegistry, len(*in))
+		copy(*out, *in)
+	}
+	out.NpmCABundle = in.NpmCABundle
+	out.NpmProxy = in.NpmProxy
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHDHPlugins.
@@ -352,18 +920,31 @@ func (in *RHDHPlugins) DeepCopy() *RHDHPlugins {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Resource) DeepCopyInto(out *Resource) {
+func (in *ScopedNpmRegistry) DeepCopyInto(out *ScopedNpmRegistry) {
 	*out = *in
-	out.Requests = in.Requests
-	out.Limits = in.Limits
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resource.
-func (in *Resource) DeepCopy() *Resource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScopedNpmRegistry.
+func (in *ScopedNpmRegistry) DeepCopy() *ScopedNpmRegistry {
 	if in == nil {
 		return nil
 	}
-	out := new(Resource)
+	out := new(ScopedNpmRegistry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeySelector) DeepCopyInto(out *SecretKeySelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeySelector.
+func (in *SecretKeySelector) DeepCopy() *SecretKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeySelector)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -392,6 +973,13 @@ func (in *SecretRefBS) DeepCopy() *SecretRefBS {
 func (in *ServerlessOperator) DeepCopyInto(out *ServerlessOperator) {
 	*out = *in
 	out.Subscription = in.Subscription
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]Broker, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerlessOperator.
@@ -423,7 +1011,12 @@ func (in *SonataFlowOperator) DeepCopy() *SonataFlowOperator {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SonataFlowPlatform) DeepCopyInto(out *SonataFlowPlatform) {
 	*out = *in
-	out.Resources = in.Resources
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Build.DeepCopyInto(&out.Build)
+	out.DevMode = in.DevMode
+	in.Eventing.DeepCopyInto(&out.Eventing)
+	in.DataIndex.DeepCopyInto(&out.DataIndex)
+	in.JobService.DeepCopyInto(&out.JobService)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonataFlowPlatform.
@@ -465,3 +1058,70 @@ func (in *Tekton) DeepCopy() *Tekton {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Workflow) DeepCopyInto(out *Workflow) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(WorkflowConfigMapSource)
+		**out = **in
+	}
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(WorkflowGitSource)
+		**out = **in
+	}
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecretRefs != nil {
+		in, out := &in.SecretRefs, &out.SecretRefs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Workflow.
+func (in *Workflow) DeepCopy() *Workflow {
+	if in == nil {
+		return nil
+	}
+	out := new(Workflow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowConfigMapSource) DeepCopyInto(out *WorkflowConfigMapSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowConfigMapSource.
+func (in *WorkflowConfigMapSource) DeepCopy() *WorkflowConfigMapSource {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowConfigMapSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowGitSource) DeepCopyInto(out *WorkflowGitSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowGitSource.
+func (in *WorkflowGitSource) DeepCopy() *WorkflowGitSource {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowGitSource)
+	in.DeepCopyInto(out)
+	return out
+}
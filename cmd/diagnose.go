@@ -0,0 +1,159 @@
+//
+// Copyright (c) 2024 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/parodos-dev/orchestrator-operator/internal/controller"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
+)
+
+// diagnosticsDir is the top directory of the diagnostics tarball.
+const diagnosticsDir = "orchestrator-diagnostics"
+
+// runDiagnose collects the resources and logs of the orchestrators for a
+// support case into a tarball, and prints the summary of the known failure
+// patterns found.
+func runDiagnose(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("diagnose", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var kubeconfig, namespace, operatorNamespace, output string
+	var logLines int64
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig, defaults to the KUBECONFIG environment variable and ~/.kube/config.")
+	flags.StringVar(&namespace, "namespace", "", "Namespace of the orchestrators, all namespaces when empty.")
+	flags.StringVar(&operatorNamespace, "operator-namespace", "orchestrator-operator-system",
+		"Namespace of the orchestrator operator, whose logs are collected.")
+	flags.Int64Var(&logLines, "log-lines", 1000, "Number of lines collected from the end of each container log, all when 0.")
+	flags.StringVar(&output, "o", "", "Path of the tarball, defaults to "+diagnosticsDir+"-<time>.tar.gz.")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	ctrl.SetLogger(zap.New(zap.WriteTo(io.Discard)))
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	diagnostics := controller.Diagnose(context.Background(), c, clientset, scheme, controller.DiagnoseOptions{
+		Namespace:         namespace,
+		OperatorNamespace: operatorNamespace,
+		LogLines:          logLines,
+	})
+	if output == "" {
+		output = fmt.Sprintf("%s-%s.tar.gz", diagnosticsDir, diagnostics.CollectedAt.Format("20060102-150405"))
+	}
+	if err := writeDiagnostics(output, diagnostics); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprint(stdout, diagnostics.Summary())
+	fmt.Fprintf(stdout, "\nDiagnostics written to %s\n", output)
+	return 0
+}
+
+// writeDiagnostics writes the summary, the resources and the logs into a
+// gzipped tarball.
+func writeDiagnostics(output string, diagnostics *controller.Diagnostics) error {
+	// the logs and the resources may hold sensitive data
+	f, err := os.OpenFile(output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// an existing file keeps its mode when truncated
+	if err := f.Chmod(0o600); err != nil {
+		return err
+	}
+	compressed := gzip.NewWriter(f)
+	archive := tar.NewWriter(compressed)
+	write := func(name string, content []byte) error {
+		header := &tar.Header{
+			Name:    path.Join(diagnosticsDir, name),
+			Mode:    0o600,
+			Size:    int64(len(content)),
+			ModTime: diagnostics.CollectedAt,
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := archive.Write(content)
+		return err
+	}
+
+	if err := write("summary.txt", []byte(diagnostics.Summary())); err != nil {
+		return err
+	}
+	for _, object := range diagnostics.Objects {
+		content, err := yaml.Marshal(object.Object)
+		if err != nil {
+			return err
+		}
+		namespace := object.GetNamespace()
+		if namespace == "" {
+			namespace = "cluster"
+		}
+		name := path.Join("resources", namespace, strings.ToLower(object.GetKind()), object.GetName()+".yaml")
+		if err := write(name, content); err != nil {
+			return err
+		}
+	}
+	logs := make([]string, 0, len(diagnostics.Logs))
+	for key := range diagnostics.Logs {
+		logs = append(logs, key)
+	}
+	sort.Strings(logs)
+	for _, key := range logs {
+		if err := write(path.Join("logs", key+".log"), diagnostics.Logs[key]); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	if err := compressed.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -25,15 +25,21 @@ import (
 	"knative.dev/operator/pkg/apis/operator/v1beta1"
 	"os"
 	"redhat-developer/red-hat-developer-hub-operator/api/v1alpha1"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -41,6 +47,7 @@ import (
 
 	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
 	"github.com/parodos-dev/orchestrator-operator/internal/controller"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -64,11 +71,22 @@ func init() {
 }
 
 func main() {
+	// the subcommands run without the manager
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "render":
+			os.Exit(runRender(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		case "diagnose":
+			os.Exit(runDiagnose(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var subscriptionNamespaces string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -78,6 +96,10 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&subscriptionNamespaces, "subscription-namespaces",
+		"openshift-serverless-logic,openshift-serverless,rhdh-operator",
+		"The comma-separated namespaces of the operator subscriptions whose Subscriptions and CSVs are watched. "+
+			"Empty watches every namespace.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -106,8 +128,36 @@ func main() {
 		TLSOpts: tlsOpts,
 	})
 
+	// the watched ConfigMaps, Secrets and Jobs are the ones created by the
+	// orchestrator and the definitions of the workflows, the others are read
+	// from the API server without caching them; the Subscriptions and CSVs
+	// are only watched in the namespaces of the subscriptions
+	createdBy := labels.SelectorFromSet(kube.AddLabel())
+	subscriptions := map[string]cache.Config{}
+	for _, namespace := range strings.Split(subscriptionNamespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			subscriptions[namespace] = cache.Config{}
+		}
+	}
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.ConfigMap{}: {Namespaces: map[string]cache.Config{
+					controller.SonataFlowNamespace: {LabelSelector: labels.Everything()},
+					cache.AllNamespaces:            {LabelSelector: createdBy},
+				}},
+				&corev1.Secret{}:                           {Label: createdBy},
+				&batchv1.Job{}:                             {Label: createdBy},
+				&operatorsv1alpha1.Subscription{}:          {Namespaces: subscriptions},
+				&operatorsv1alpha1.ClusterServiceVersion{}: {Namespaces: subscriptions},
+			},
+		},
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				DisableFor: []client.Object{&corev1.ConfigMap{}, &corev1.Secret{}, &corev1.Service{}},
+			},
+		},
 		Metrics: metricsserver.Options{
 			BindAddress:   metricsAddr,
 			SecureServing: secureMetrics,
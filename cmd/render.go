@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2024 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	configv1 "github.com/openshift/api/config/v1"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
+)
+
+// runRender writes the manifests the operator would create for an
+// Orchestrator to stdout, without cluster access. The input holds the
+// Orchestrator and, optionally, the objects expected on the cluster.
+func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var file, clusterDomain string
+	flags.StringVar(&file, "f", "-",
+		"File with the Orchestrator and the objects expected on the cluster, such as the namespace of the RHDH operator, "+
+			"the PostgreSQL service or the referenced secrets. Reads stdin when set to '-'.")
+	flags.StringVar(&clusterDomain, "cluster-domain", "", "Domain of the OpenShift cluster used in the Backstage configuration.")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	// the failures are reported in the summary, the component logs are noise here
+	ctrl.SetLogger(zap.New(zap.WriteTo(io.Discard)))
+
+	input := stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+		input = f
+	}
+	orchestrator, existing, err := readObjects(input)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if clusterDomain != "" {
+		existing = append(existing, &configv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Spec:       configv1.IngressSpec{Domain: clusterDomain},
+		})
+	}
+
+	manifests, renderErr := controller.Render(context.Background(), scheme, orchestrator, existing)
+	if err := writeManifests(stdout, manifests); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if renderErr != nil {
+		fmt.Fprintf(stderr, "Some resources were not rendered:\n%v\n", renderErr)
+		// the objects missing offline are reported without failing the render
+		if !onlyMissingPrerequisites(renderErr) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// readObjects decodes the YAML documents of the input into the Orchestrator
+// and the other objects.
+func readObjects(input io.Reader) (*orchestratorv1alpha1.Orchestrator, []client.Object, error) {
+	var orchestrator *orchestratorv1alpha1.Orchestrator
+	existing := []client.Object{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bufio.NewReader(input), 4096)
+	for {
+		document := &unstructured.Unstructured{}
+		if err := decoder.Decode(&document.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, err
+		}
+		if len(document.Object) == 0 {
+			continue
+		}
+		typed, err := scheme.New(document.GroupVersionKind())
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(document.Object, typed); err != nil {
+			return nil, nil, fmt.Errorf("decoding %s %s: %w", document.GetKind(), document.GetName(), err)
+		}
+		if o, ok := typed.(*orchestratorv1alpha1.Orchestrator); ok {
+			if orchestrator != nil {
+				return nil, nil, fmt.Errorf("found more than one Orchestrator: %s and %s", orchestrator.Name, o.Name)
+			}
+			orchestrator = o
+			continue
+		}
+		object, ok := typed.(client.Object)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported kind %s", document.GetKind())
+		}
+		existing = append(existing, object)
+	}
+	if orchestrator == nil {
+		return nil, nil, errors.New("no Orchestrator found in the input")
+	}
+	return orchestrator, existing, nil
+}
+
+func writeManifests(output io.Writer, manifests []*unstructured.Unstructured) error {
+	for _, manifest := range manifests {
+		content, err := yaml.Marshal(manifest.Object)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(output, "---\n%s", content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func onlyMissingPrerequisites(err error) bool {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return kube.ErrorKindOf(err) == kube.MissingPrerequisite
+	}
+	for _, err := range joined.Unwrap() {
+		if kube.ErrorKindOf(err) != kube.MissingPrerequisite {
+			return false
+		}
+	}
+	return true
+}
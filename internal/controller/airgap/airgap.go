@@ -0,0 +1,280 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package airgap
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/rhdh"
+)
+
+// Default hosts of the SCM integrations, used when an integration sets no host.
+const (
+	GithubHost         = "github.com"
+	GitlabHost         = "gitlab.com"
+	BitbucketCloudHost = "bitbucket.org"
+	AzureHost          = "dev.azure.com"
+)
+
+// Apply returns a copy of the spec in which the references generated by the
+// operator point to the mirrors of the air-gapped profile: subscriptions use
+// the mirrored catalog source, the .npmrc and plugin packages resolve from the
+// NPM mirror, the images are pulled from the mirror registry and the catalog
+// locations and workflow repositories are read from the Git mirror. It also
+// returns the references that can't be mirrored, sorted, such as the SCM
+// integrations or the images without a mirror registry. The spec is returned
+// unchanged when the profile is disabled.
+func Apply(spec orchestratorv1alpha1.OrchestratorSpec) (orchestratorv1alpha1.OrchestratorSpec, []string) {
+	airGapped := spec.AirGapped
+	if !airGapped.Enabled {
+		return spec, nil
+	}
+	spec = *spec.DeepCopy()
+	checker := newHostChecker(airGapped)
+	refs := map[string]bool{}
+	mirrorImage := func(image *string, ref string) {
+		if !checker.isExternalURL(*image) {
+			return
+		}
+		if airGapped.MirrorRegistry == "" {
+			refs[fmt.Sprintf("%s %s", ref, *image)] = true
+			return
+		}
+		*image = MirrorImage(*image, airGapped.MirrorRegistry)
+	}
+	mirrorRepository := func(repository *string, ref string) {
+		if !checker.isExternalURL(*repository) {
+			return
+		}
+		if airGapped.GitMirror == "" {
+			refs[fmt.Sprintf("%s %s", ref, *repository)] = true
+			return
+		}
+		*repository = MirrorURL(*repository, airGapped.GitMirror)
+	}
+
+	// OLM subscriptions
+	for _, subscription := range []*orchestratorv1alpha1.Subscription{
+		&spec.SonataFlowOperator.Subscription,
+		&spec.ServerlessOperator.Subscription,
+		&spec.RhdhOperator.Subscription,
+	} {
+		if subscription.Name == "" {
+			continue
+		}
+		if airGapped.CatalogSource.Name == "" {
+			refs[fmt.Sprintf("subscription %s uses catalog source %s/%s", subscription.Name,
+				sourceNamespace(*subscription), subscription.SourceName)] = true
+			continue
+		}
+		subscription.SourceName = airGapped.CatalogSource.Name
+		subscription.SourceNamespace = airGapped.CatalogSource.Namespace
+	}
+
+	// NPM registries of the .npmrc and the plugin packages
+	plugins := &spec.RhdhPlugins
+	if airGapped.NpmRegistry != "" {
+		plugins.NpmRegistry = airGapped.NpmRegistry
+		for i := range plugins.ScopedRegistries {
+			plugins.ScopedRegistries[i].Registry = airGapped.NpmRegistry
+		}
+	}
+	if checker.isExternalURL(plugins.NpmRegistry) {
+		refs[fmt.Sprintf("npm registry %s", plugins.NpmRegistry)] = true
+	}
+	for _, scoped := range plugins.ScopedRegistries {
+		if checker.isExternalURL(scoped.Registry) {
+			refs[fmt.Sprintf("npm registry %s for scope %s", scoped.Registry, scoped.Scope)] = true
+		}
+	}
+
+	// catalog locations. The default locations of the workflow templates
+	// repository are listed in the spec to read them from the Git mirror,
+	// unless they are loaded from the templates configmap
+	catalog := &spec.RhdhOperator.Catalog
+	if !catalog.ExcludeDefaultLocations && catalog.TemplatesConfigMap == "" && checker.isExternalURL(rhdh.DefaultCatalogRepository) {
+		if airGapped.GitMirror == "" {
+			refs[fmt.Sprintf("catalog default locations %s", rhdh.DefaultCatalogRepository)] = true
+		} else {
+			catalog.Locations = append(rhdh.DefaultCatalogLocations(spec.RhdhOperator), catalog.Locations...)
+			catalog.ExcludeDefaultLocations = true
+		}
+	}
+	for i := range catalog.Locations {
+		if location := &catalog.Locations[i]; location.Type == orchestratorv1alpha1.URLCatalogLocation {
+			mirrorRepository(&location.Target, "catalog location")
+		}
+	}
+
+	// SCM integrations
+	integrations := spec.RhdhOperator.Integrations
+	integrationHosts := map[string][]string{}
+	if spec.RhdhOperator.SecretRef.Github.Token.Key != "" {
+		integrationHosts["github"] = append(integrationHosts["github"], GithubHost)
+	}
+	for _, github := range integrations.Github {
+		integrationHosts["github"] = append(integrationHosts["github"], defaultHost(github.Host, GithubHost))
+	}
+	for _, gitlab := range integrations.Gitlab {
+		integrationHosts["gitlab"] = append(integrationHosts["gitlab"], defaultHost(gitlab.Host, GitlabHost))
+	}
+	if len(integrations.BitbucketCloud) > 0 {
+		integrationHosts["bitbucketCloud"] = append(integrationHosts["bitbucketCloud"], BitbucketCloudHost)
+	}
+	for _, bitbucket := range integrations.BitbucketServer {
+		integrationHosts["bitbucketServer"] = append(integrationHosts["bitbucketServer"], bitbucket.Host)
+	}
+	for _, azure := range integrations.Azure {
+		integrationHosts["azure"] = append(integrationHosts["azure"], defaultHost(azure.Host, AzureHost))
+	}
+	for integration, hosts := range integrationHosts {
+		for _, host := range hosts {
+			if checker.isExternalHost(host) {
+				refs[fmt.Sprintf("%s integration %s", integration, host)] = true
+			}
+		}
+	}
+
+	// workflow definitions fetched from Git, workflow images and the image of
+	// the workflow database setup Jobs
+	for i := range spec.Workflows {
+		workflow := &spec.Workflows[i]
+		if workflow.Git != nil {
+			mirrorRepository(&workflow.Git.Repository, fmt.Sprintf("workflow %s repository", workflow.Name))
+		}
+		mirrorImage(&workflow.Image, fmt.Sprintf("workflow %s image", workflow.Name))
+	}
+	if len(spec.Workflows) > 0 {
+		mirrorImage(&spec.PostgresDB.ClientImage, "postgres client image")
+	}
+
+	// images of the SonataFlow platform. The workflow images are built into
+	// the mirror registry unless another registry is set
+	platform := &spec.OrchestratorPlatform.SonataFlowPlatform
+	if airGapped.MirrorRegistry != "" && platform.Build.Registry.Address == "" {
+		platform.Build.Registry.Address = airGapped.MirrorRegistry
+	}
+	mirrorImage(&platform.Build.BaseImage, "platform build base image")
+	mirrorImage(&platform.DevMode.BaseImage, "platform dev mode base image")
+	mirrorImage(&platform.DataIndex.PodTemplate.Image, "data index image")
+	mirrorImage(&platform.JobService.PodTemplate.Image, "job service image")
+
+	externalRefs := make([]string, 0, len(refs))
+	for ref := range refs {
+		externalRefs = append(externalRefs, ref)
+	}
+	sort.Strings(externalRefs)
+	return spec, externalRefs
+}
+
+// MirrorImage returns the reference of the image in the mirror registry,
+// keeping its repository path, tag and digest: quay.io/org/app:1.0 is
+// mirror.example.com/org/app:1.0 in the mirror.example.com registry.
+func MirrorImage(image, mirror string) string {
+	repository := image
+	if registry, path, found := strings.Cut(image, "/"); found && (strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		repository = path
+	}
+	return strings.TrimSuffix(mirror, "/") + "/" + repository
+}
+
+// MirrorURL returns the URL of the repository in the Git mirror, which serves
+// the repositories under their host and path.
+func MirrorURL(rawURL, mirror string) string {
+	refURL, err := url.Parse(rawURL)
+	if err != nil || refURL.Host == "" {
+		return rawURL
+	}
+	mirrored := strings.TrimSuffix(mirror, "/") + "/" + refURL.Host + refURL.EscapedPath()
+	if refURL.RawQuery != "" {
+		mirrored += "?" + refURL.RawQuery
+	}
+	return mirrored
+}
+
+// hostChecker tells whether a host is reachable from an air-gapped cluster:
+// the mirrors, the allowed hosts and cluster-local services.
+type hostChecker struct {
+	allowedHosts map[string]bool
+}
+
+func newHostChecker(airGapped orchestratorv1alpha1.AirGapped) hostChecker {
+	checker := hostChecker{allowedHosts: map[string]bool{}}
+	for _, ref := range append([]string{airGapped.MirrorRegistry, airGapped.NpmRegistry, airGapped.GitMirror}, airGapped.AllowedHosts...) {
+		if host := hostOf(ref); host != "" {
+			checker.allowedHosts[host] = true
+		}
+	}
+	return checker
+}
+
+func (c hostChecker) isExternalURL(rawURL string) bool {
+	if rawURL == "" {
+		return false
+	}
+	return c.isExternalHost(hostOf(rawURL))
+}
+
+func (c hostChecker) isExternalHost(host string) bool {
+	if host == "" || c.allowedHosts[host] {
+		return false
+	}
+	return !isClusterLocal(host)
+}
+
+// hostOf returns the host name of a URL or of a registry reference without
+// scheme, such as registry.example.com:5000/org.
+func hostOf(ref string) string {
+	if !strings.Contains(ref, "://") {
+		ref = "//" + ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return refURL.Hostname()
+}
+
+func isClusterLocal(host string) bool {
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsPrivate() || ip.IsLoopback()
+	}
+	return strings.HasSuffix(host, ".svc") || strings.HasSuffix(host, ".svc.cluster.local")
+}
+
+func sourceNamespace(subscription orchestratorv1alpha1.Subscription) string {
+	if subscription.SourceNamespace == "" {
+		return kube.CatalogSourceNamespace
+	}
+	return subscription.SourceNamespace
+}
+
+func defaultHost(host, defaultHost string) string {
+	if host == "" {
+		return defaultHost
+	}
+	return host
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package airgap
+
+import (
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Air-gapped profile", func() {
+	var spec orchestratorv1alpha1.OrchestratorSpec
+
+	BeforeEach(func() {
+		spec = orchestratorv1alpha1.OrchestratorSpec{
+			SonataFlowOperator: orchestratorv1alpha1.SonataFlowOperator{
+				Subscription: orchestratorv1alpha1.Subscription{Name: "logic-operator-rhel8", SourceName: "redhat-operators"},
+			},
+			RhdhOperator: orchestratorv1alpha1.RHDHOperator{
+				Subscription: orchestratorv1alpha1.Subscription{Name: "rhdh", SourceName: "redhat-operators"},
+				SecretRef:    orchestratorv1alpha1.SecretRefBS{Github: orchestratorv1alpha1.GithubBS{Token: orchestratorv1alpha1.SecretKeySelector{Key: "GITHUB_TOKEN"}}},
+				Catalog: orchestratorv1alpha1.Catalog{
+					Locations: []orchestratorv1alpha1.CatalogLocation{
+						{Type: orchestratorv1alpha1.URLCatalogLocation, Target: "https://git.example.com/org/catalog/all.yaml"},
+						{Type: orchestratorv1alpha1.FileCatalogLocation, Target: "/opt/app-root/src/entities.yaml"},
+					},
+				},
+				Integrations: orchestratorv1alpha1.Integrations{
+					Gitlab: []orchestratorv1alpha1.GitIntegration{{Host: "git.example.com", Token: orchestratorv1alpha1.SecretKeySelector{Key: "GITLAB_TOKEN"}}},
+				},
+			},
+			RhdhPlugins: orchestratorv1alpha1.RHDHPlugins{
+				NpmRegistry: "https://npm.registry.redhat.com",
+				ScopedRegistries: []orchestratorv1alpha1.ScopedNpmRegistry{
+					{Scope: "@myorg", Registry: "https://npm.myorg.com"},
+				},
+			},
+		}
+	})
+
+	It("should leave the spec unchanged when disabled", func() {
+		applied, externalRefs := Apply(spec)
+		Expect(applied).To(Equal(spec))
+		Expect(externalRefs).To(BeEmpty())
+	})
+
+	It("should point the generated references to the mirrors", func() {
+		spec.AirGapped = orchestratorv1alpha1.AirGapped{
+			Enabled:       true,
+			CatalogSource: orchestratorv1alpha1.MirroredCatalogSource{Name: "mirrored-operators", Namespace: "openshift-marketplace"},
+			NpmRegistry:   "http://verdaccio.npm-mirror.svc:4873",
+			AllowedHosts:  []string{"git.example.com"},
+		}
+		spec.RhdhOperator.Catalog.TemplatesConfigMap = "workflow-templates"
+		applied, externalRefs := Apply(spec)
+
+		Expect(applied.SonataFlowOperator.Subscription.SourceName).To(Equal("mirrored-operators"))
+		Expect(applied.RhdhOperator.Subscription.SourceName).To(Equal("mirrored-operators"))
+		Expect(applied.ServerlessOperator.Subscription.SourceName).To(BeEmpty())
+		Expect(applied.RhdhPlugins.NpmRegistry).To(Equal("http://verdaccio.npm-mirror.svc:4873"))
+		Expect(applied.RhdhPlugins.ScopedRegistries[0].Registry).To(Equal("http://verdaccio.npm-mirror.svc:4873"))
+		Expect(externalRefs).To(Equal([]string{"github integration github.com"}))
+
+		// the original spec is not modified
+		Expect(spec.RhdhPlugins.ScopedRegistries[0].Registry).To(Equal("https://npm.myorg.com"))
+	})
+
+	It("should report the references without a mirror", func() {
+		spec.AirGapped = orchestratorv1alpha1.AirGapped{Enabled: true}
+		_, externalRefs := Apply(spec)
+		Expect(externalRefs).To(Equal([]string{
+			"catalog default locations https://github.com/parodos-dev/workflow-software-templates",
+			"catalog location https://git.example.com/org/catalog/all.yaml",
+			"github integration github.com",
+			"gitlab integration git.example.com",
+			"npm registry https://npm.myorg.com for scope @myorg",
+			"npm registry https://npm.registry.redhat.com",
+			"subscription logic-operator-rhel8 uses catalog source openshift-marketplace/redhat-operators",
+			"subscription rhdh uses catalog source openshift-marketplace/redhat-operators",
+		}))
+	})
+
+	It("should pull the images from the mirror registry", func() {
+		spec.AirGapped = orchestratorv1alpha1.AirGapped{Enabled: true, MirrorRegistry: "mirror.example.com:5000/orchestrator"}
+		spec.Workflows = []orchestratorv1alpha1.Workflow{
+			{Name: "greeting", Image: "quay.io/orchestrator/greeting:1.0"},
+			{Name: "onboarding", Image: "mirror.example.com:5000/orchestrator/onboarding:1.0"},
+		}
+		spec.PostgresDB.ClientImage = "registry.redhat.io/rhel9/postgresql-15@sha256:0123"
+		spec.OrchestratorPlatform.SonataFlowPlatform.JobService.PodTemplate.Image = "registry.redhat.io/openshift-serverless-1/logic-jobs-service-postgresql-rhel8:1.33"
+		applied, externalRefs := Apply(spec)
+
+		Expect(applied.Workflows[0].Image).To(Equal("mirror.example.com:5000/orchestrator/orchestrator/greeting:1.0"))
+		Expect(applied.Workflows[1].Image).To(Equal("mirror.example.com:5000/orchestrator/onboarding:1.0"))
+		Expect(applied.PostgresDB.ClientImage).To(Equal("mirror.example.com:5000/orchestrator/rhel9/postgresql-15@sha256:0123"))
+		platform := applied.OrchestratorPlatform.SonataFlowPlatform
+		Expect(platform.JobService.PodTemplate.Image).To(Equal(
+			"mirror.example.com:5000/orchestrator/openshift-serverless-1/logic-jobs-service-postgresql-rhel8:1.33"))
+		Expect(platform.Build.Registry.Address).To(Equal("mirror.example.com:5000/orchestrator"))
+		Expect(externalRefs).NotTo(ContainElement(ContainSubstring("image")))
+	})
+
+	It("should report the images without a mirror registry", func() {
+		spec.AirGapped = orchestratorv1alpha1.AirGapped{Enabled: true}
+		spec.Workflows = []orchestratorv1alpha1.Workflow{{Name: "greeting", Image: "quay.io/orchestrator/greeting:1.0"}}
+		spec.PostgresDB.ClientImage = "registry.redhat.io/rhel9/postgresql-15:latest"
+		applied, externalRefs := Apply(spec)
+		Expect(applied.Workflows[0].Image).To(Equal("quay.io/orchestrator/greeting:1.0"))
+		Expect(externalRefs).To(ContainElements(
+			"workflow greeting image quay.io/orchestrator/greeting:1.0",
+			"postgres client image registry.redhat.io/rhel9/postgresql-15:latest",
+		))
+	})
+
+	It("should read the catalog locations and the workflow repositories from the Git mirror", func() {
+		spec.AirGapped = orchestratorv1alpha1.AirGapped{Enabled: true, GitMirror: "https://git.mirror.example.com/mirrors/"}
+		spec.RhdhOperator.CatalogBranch = "v1.2.x"
+		spec.Workflows = []orchestratorv1alpha1.Workflow{
+			{Name: "greeting", Git: &orchestratorv1alpha1.WorkflowGitSource{Repository: "https://github.com/org/workflows", Path: "greeting.sw.yaml"}},
+		}
+		applied, externalRefs := Apply(spec)
+
+		catalog := applied.RhdhOperator.Catalog
+		Expect(catalog.ExcludeDefaultLocations).To(BeTrue())
+		Expect(catalog.Locations).To(ContainElements(
+			orchestratorv1alpha1.CatalogLocation{
+				Type:   orchestratorv1alpha1.URLCatalogLocation,
+				Target: "https://git.mirror.example.com/mirrors/github.com/parodos-dev/workflow-software-templates/blob/v1.2.x/entities/workflow-resources.yaml",
+			},
+			orchestratorv1alpha1.CatalogLocation{
+				Type:   orchestratorv1alpha1.URLCatalogLocation,
+				Target: "https://git.mirror.example.com/mirrors/git.example.com/org/catalog/all.yaml",
+			},
+			orchestratorv1alpha1.CatalogLocation{Type: orchestratorv1alpha1.FileCatalogLocation, Target: "/opt/app-root/src/entities.yaml"},
+		))
+		Expect(applied.Workflows[0].Git.Repository).To(Equal("https://git.mirror.example.com/mirrors/github.com/org/workflows"))
+		Expect(externalRefs).NotTo(ContainElement(HavePrefix("catalog")))
+		Expect(externalRefs).NotTo(ContainElement(ContainSubstring("repository")))
+
+		// the original spec is not modified
+		Expect(spec.RhdhOperator.Catalog.Locations).To(HaveLen(2))
+		Expect(spec.Workflows[0].Git.Repository).To(Equal("https://github.com/org/workflows"))
+	})
+})
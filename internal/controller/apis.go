@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/rhdh"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// APIs required by the components. The OLM APIs are needed to subscribe the
+// operators, which then serve the APIs of the resources of the component.
+var (
+	olmAPIs = []schema.GroupVersionKind{
+		schema.FromAPIVersionAndKind("operators.coreos.com/v1alpha1", "Subscription"),
+	}
+	sonataFlowAPIs = []schema.GroupVersionKind{
+		schema.FromAPIVersionAndKind(SonataFlowAPIVersion, SonataFlowClusterPlatformKind),
+		schema.FromAPIVersionAndKind(SonataFlowAPIVersion, SonataFlowPlatformKind),
+	}
+	workflowAPIs = []schema.GroupVersionKind{
+		schema.FromAPIVersionAndKind(SonataFlowAPIVersion, SonataFlowKind),
+	}
+	knativeAPIs = []schema.GroupVersionKind{
+		schema.FromAPIVersionAndKind(KnativeAPIVersion, KnativeEventingKind),
+		schema.FromAPIVersionAndKind(KnativeAPIVersion, KnativeServingKind),
+	}
+	brokerAPIs = []schema.GroupVersionKind{
+		schema.FromAPIVersionAndKind(KnativeBrokerAPIVersion, KnativeBrokerKind),
+		schema.FromAPIVersionAndKind(KnativeBrokerAPIVersion, KnativeTriggerKind),
+	}
+	// served by Knative Kafka, installed with the broker enabled through the
+	// KnativeKafka resource of OpenShift Serverless
+	kafkaBrokerAPIs = []schema.GroupVersionKind{
+		schema.FromAPIVersionAndKind("eventing.knative.dev/v1alpha1", "KafkaSink"),
+	}
+	backstageAPIs = []schema.GroupVersionKind{
+		schema.FromAPIVersionAndKind(rhdh.BackstageAPIVersion, rhdh.BackstageKind),
+	}
+	openShiftConfigAPIs = []schema.GroupVersionKind{
+		schema.FromAPIVersionAndKind("config.openshift.io/v1", "Ingress"),
+	}
+)
+
+// missingAPIsError is returned when a component can't proceed until the
+// cluster serves its APIs. It is not a failure: the CRD watches reconcile the
+// component again once the CRDs are established.
+type missingAPIsError struct {
+	component string
+	apis      []string
+}
+
+func (e *missingAPIsError) Error() string {
+	return fmt.Sprintf("%s is waiting for the APIs %s to be served", e.component, strings.Join(e.apis, ", "))
+}
+
+// missingAPIs returns the APIs that are not served by the cluster. The
+// discovery is cached by the REST mapper, which refreshes it when an API
+// group is not known yet.
+func missingAPIs(c client.Client, apis []schema.GroupVersionKind) ([]string, error) {
+	missing := []string{}
+	for _, gvk := range apis {
+		_, err := c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			missing = append(missing, gvk.Kind+"."+gvk.GroupVersion().String())
+			continue
+		}
+		if err != nil {
+			return nil, kube.WrapError(kube.TransientAPI, err)
+		}
+	}
+	return missing, nil
+}
+
+// checkAPIs returns a missingAPIsError when the APIs needed by the component
+// are not all served. The error is reported by reportComponentReady.
+func checkAPIs(ctx context.Context, env ComponentEnv, component string, apis []schema.GroupVersionKind) error {
+	missing, err := missingAPIs(env.Client, apis)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	log.FromContext(ctx).Info("APIs not served yet", "Component", component, "APIs", missing)
+	return &missingAPIsError{component: component, apis: missing}
+}
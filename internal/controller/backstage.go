@@ -0,0 +1,215 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/rhdh"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// backstageComponent installs the RHDH operator and the Backstage instance
+// with the orchestrator plugins.
+type backstageComponent struct{}
+
+func (c *backstageComponent) Name() string { return metrics.ComponentBackstage }
+
+func (c *backstageComponent) ConditionType() string { return TypeBackstageReady }
+
+// DependsOn returns SonataFlow, as the orchestrator plugin is configured with
+// the data index service of the platform.
+func (c *backstageComponent) DependsOn() []string { return []string{metrics.ComponentSonataFlow} }
+
+func (c *backstageComponent) Enabled(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.RhdhOperator.Enabled
+}
+
+func (c *backstageComponent) Paused(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.RhdhOperator.Paused
+}
+
+// Prerequisites checks that the namespace of the subscription exists, as it
+// is shared with the other RHDH instances and is not created by the
+// orchestrator.
+func (c *backstageComponent) Prerequisites(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	namespace := spec.RhdhOperator.Subscription.Namespace
+	if _, err := kube.CheckNamespaceExist(ctx, env.Client, namespace); err != nil {
+		log.FromContext(ctx).Error(err, "Error occurred when checking namespace exists", "NS", namespace)
+		return err
+	}
+	return nil
+}
+
+func (c *backstageComponent) Install(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	return installOperator(ctx, env, c.Name(), rhdh.BackstageOperatorGroup, spec.RhdhOperator.Subscription)
+}
+
+func (c *backstageComponent) Reconcile(
+	ctx context.Context,
+	env ComponentEnv,
+	spec orchestratorv1alpha1.OrchestratorSpec) (ComponentResult, error) {
+	logger := log.FromContext(ctx)
+	rhdhOperator := spec.RhdhOperator
+	plugins := spec.RhdhPlugins
+	targetNamespace := rhdhOperator.Subscription.TargetNamespace
+
+	clusterDomain, _ := getClusterDomain(ctx, env.Client)
+	// create or sync npmrc secret
+	if err := rhdh.HandleNpmrcSecret(rhdh.RegistrySecretName, targetNamespace, plugins, ctx, env.Client); err != nil {
+		return ComponentResult{}, err
+	}
+	// verify plugin integrity before the backstage CR picks up the plugins
+	result := ComponentResult{}
+	if plugins.VerifyIntegrity {
+		condition, err := verifyPlugins(ctx, env, rhdhOperator, plugins)
+		result.Conditions = append(result.Conditions, condition)
+		if err != nil {
+			return result, err
+		}
+	}
+	// validate the credentials referenced from the backstage secret
+	secretRefs := rhdh.BackstageSecretRefs(rhdhOperator, plugins)
+	if err := rhdh.ValidateSecretRefs(ctx, env.Client, targetNamespace, secretRefs); err != nil {
+		logger.Error(err, "Error occurred when validating backstage secret", "Secret", rhdhOperator.SecretRef.Name)
+		return result, err
+	}
+	// check the workflow templates mounted into backstage
+	if err := rhdh.ValidateTemplatesConfigMap(rhdhOperator, ctx, env.Client); err != nil {
+		return result, err
+	}
+	// create backstage CR once the operator serves the Backstage API
+	if err := checkAPIs(ctx, env, c.Name(), backstageAPIs); err != nil {
+		return result, err
+	}
+	if err := rhdh.HandleCRCreation(rhdhOperator, plugins, clusterDomain, ctx, env.Client); err != nil {
+		return result, err
+	}
+	metrics.SetPluginVersions(ctx, rhdh.PluginVersions(plugins.Scope))
+	return result, nil
+}
+
+func (c *backstageComponent) Status(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) (ComponentStatus, error) {
+	return subscriptionStatus(ctx, env, spec.RhdhOperator.Subscription)
+}
+
+// Cleanup removes the Backstage resources created by the orchestrator, then
+// the subscription, which is left behind when other Backstage instances
+// share the namespace.
+func (c *backstageComponent) Cleanup(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	logger := log.FromContext(ctx)
+	if err := rhdh.HandleBackstageCleanup(ctx, env.Client, env.OLMClient); err != nil {
+		return err
+	}
+
+	subscription := spec.RhdhOperator.Subscription
+	subscriptionExists, _, err := kube.CheckSubscriptionExists(ctx, env.OLMClient, subscription.Namespace, subscription.Name)
+	if err != nil {
+		logger.Error(err, "Error occurred when checking subscription exists", "SubscriptionName", subscription.Name)
+		return err
+	}
+	if !subscriptionExists || kube.RecordPlannedAction(ctx, kube.PlanDelete, "Subscription", subscription.Namespace, subscription.Name) {
+		return nil
+	}
+	err = env.OLMClient.OperatorsV1alpha1().Subscriptions(subscription.Namespace).Delete(ctx, subscription.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "Error occurred while deleting Subscription", "SubscriptionName", subscription.Name, "Namespace", subscription.Namespace)
+		kube.EventsFromContext(ctx).Warning(kube.ReasonCleanupFailed, "Failed to delete subscription %s/%s: %v",
+			subscription.Namespace, subscription.Name, err)
+		return err
+	}
+	logger.Info("Successfully deleted Subscription", "SubscriptionName", subscription.Name)
+	kube.EventsFromContext(ctx).Normal(kube.ReasonSubscriptionDeleted, "Deleted subscription %s/%s", subscription.Namespace, subscription.Name)
+	return nil
+}
+
+// verifyPlugins checks the plugin integrity against the configured NPM registry
+// and returns the outcome as the PluginsVerified condition.
+func verifyPlugins(
+	ctx context.Context,
+	env ComponentEnv,
+	operator orchestratorv1alpha1.RHDHOperator,
+	plugins orchestratorv1alpha1.RHDHPlugins) (metav1.Condition, error) {
+	logger := log.FromContext(ctx)
+	namespace := operator.Subscription.TargetNamespace
+
+	condition := metav1.Condition{Type: TypePluginsVerified}
+	npmrc, err := rhdh.GetNpmrc(ctx, env.Client, rhdh.RegistrySecretName, namespace)
+	if err != nil {
+		logger.Error(err, "Error occurred when reading npmrc secret", "Secret", rhdh.RegistrySecretName)
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "VerificationFailed"
+		condition.Message = err.Error()
+		return condition, err
+	}
+	npmRegistry := rhdh.PluginRegistry(plugins)
+	mismatches, err := rhdh.VerifyPluginIntegrity(ctx, rhdh.PluginVerificationClient, operator, plugins, npmRegistry, npmrc)
+	if err != nil {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "VerificationFailed"
+		condition.Message = err.Error()
+		return condition, err
+	}
+	if len(mismatches) > 0 {
+		details := make([]string, 0, len(mismatches))
+		for _, mismatch := range mismatches {
+			details = append(details, mismatch.String())
+		}
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "IntegrityMismatch"
+		condition.Message = fmt.Sprintf("Plugin integrity does not match registry %s: %s", npmRegistry, strings.Join(details, "; "))
+		// the pinned integrity or the plugin version must be fixed in the spec
+		return condition, kube.NewError(kube.InvalidSpec, "%s", condition.Message)
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = "IntegrityVerified"
+	condition.Message = fmt.Sprintf("All plugins match the integrity published in %s", npmRegistry)
+	return condition, nil
+}
+
+// getClusterDomain retrieves the OpenShift cluster domain from the Ingress resource
+func getClusterDomain(ctx context.Context, c client.Client) (string, error) {
+	gcdLogger := log.FromContext(ctx)
+	// the cluster domain is only known on OpenShift
+	if missing, err := missingAPIs(c, openShiftConfigAPIs); err != nil || len(missing) > 0 {
+		gcdLogger.Info("OpenShift Ingress API not served, cluster domain left unset")
+		return "", err
+	}
+	ingress := &configv1.Ingress{}
+	err := c.Get(ctx, client.ObjectKey{Name: "cluster"}, ingress)
+	if err != nil {
+		gcdLogger.Error(err, "Unable to retrieve OpenShift Ingress resource")
+		return "", err
+	}
+
+	clusterDomain := ingress.Spec.Domain
+	if ingress.Spec.Domain == "" {
+		gcdLogger.Error(err, "Cluster domain not set in Ingress resource")
+		return "", err
+	}
+	gcdLogger.Info("Successfully retrieved cluster domain", "Domain", clusterDomain)
+	return clusterDomain, nil
+}
@@ -0,0 +1,503 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	knative "knative.dev/operator/pkg/apis/operator/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	KnativeBrokerCRDName  = "brokers.eventing.knative.dev"
+	KnativeTriggerCRDName = "triggers.eventing.knative.dev"
+	// KafkaSinkCRDName is served by the controller of Knative Kafka, which
+	// also reconciles the Kafka brokers.
+	KafkaSinkCRDName = "kafkasinks.eventing.knative.dev"
+	// BrokerLabelKey labels the brokers declared in the spec, their
+	// ConfigMaps and their triggers with the name of the broker.
+	BrokerLabelKey = "rhdh.redhat.com/broker"
+	// BrokerConfigSuffix names the ConfigMap holding the channel
+	// configuration of a broker.
+	BrokerConfigSuffix = "-broker-config"
+
+	// the broker class is read by Knative Eventing when the broker is
+	// created, and can't be changed afterwards
+	brokerClassAnnotation = "eventing.knative.dev/broker.class"
+	inMemoryBrokerClass   = "MTChannelBasedBroker"
+	kafkaBrokerClass      = "Kafka"
+	// in-memory channels hold the events of the channel based brokers
+	brokerChannelTemplateKey = "channel-template-spec"
+	inMemoryChannelTemplate  = "apiVersion: messaging.knative.dev/v1\nkind: InMemoryChannel\n"
+	// keys of the configuration of the Kafka brokers
+	kafkaBootstrapServersKey      = "bootstrap.servers"
+	kafkaTopicPartitionsKey       = "default.topic.partitions"
+	kafkaTopicReplicationKey      = "default.topic.replication.factor"
+	kafkaAuthSecretNameKey        = "auth.secret.ref.name"
+	kafkaDefaultPartitions        = 10
+	kafkaDefaultReplicationFactor = 3
+)
+
+// newBroker returns an empty Knative Eventing broker. The brokers are handled
+// as unstructured objects, as Knative Eventing is not a dependency of the
+// operator.
+func newBroker() *unstructured.Unstructured {
+	broker := &unstructured.Unstructured{}
+	broker.SetAPIVersion(KnativeBrokerAPIVersion)
+	broker.SetKind(KnativeBrokerKind)
+	return broker
+}
+
+func newBrokerList() *unstructured.UnstructuredList {
+	brokers := &unstructured.UnstructuredList{}
+	brokers.SetAPIVersion(KnativeBrokerAPIVersion)
+	brokers.SetKind(KnativeBrokerKind + "List")
+	return brokers
+}
+
+// newTrigger returns an empty Knative Eventing trigger, handled as an
+// unstructured object like the brokers.
+func newTrigger() *unstructured.Unstructured {
+	trigger := &unstructured.Unstructured{}
+	trigger.SetAPIVersion(KnativeBrokerAPIVersion)
+	trigger.SetKind(KnativeTriggerKind)
+	return trigger
+}
+
+func newTriggerList() *unstructured.UnstructuredList {
+	triggers := &unstructured.UnstructuredList{}
+	triggers.SetAPIVersion(KnativeBrokerAPIVersion)
+	triggers.SetKind(KnativeTriggerKind + "List")
+	return triggers
+}
+
+// validateBrokers checks that each broker and trigger has a unique name and
+// that only the Kafka brokers have a Kafka configuration, with its bootstrap
+// servers.
+func validateBrokers(brokers []orchestratorv1alpha1.Broker) error {
+	names := map[string]bool{}
+	triggers := map[string]bool{}
+	for _, broker := range brokers {
+		if names[broker.Name] {
+			return kube.NewError(kube.InvalidSpec, "broker %s is declared more than once", broker.Name)
+		}
+		names[broker.Name] = true
+		for _, trigger := range broker.Triggers {
+			if triggers[trigger.Name] {
+				return kube.NewError(kube.InvalidSpec, "trigger %s is declared more than once", trigger.Name)
+			}
+			triggers[trigger.Name] = true
+			if trigger.Workflow == "" {
+				return kube.NewError(kube.InvalidSpec, "trigger %s of broker %s must set workflow", trigger.Name, broker.Name)
+			}
+		}
+		if broker.Type != orchestratorv1alpha1.KafkaBroker {
+			if broker.Kafka != nil {
+				return kube.NewError(kube.InvalidSpec, "broker %s sets kafka but is not of type %s", broker.Name, orchestratorv1alpha1.KafkaBroker)
+			}
+			continue
+		}
+		if broker.Kafka == nil || broker.Kafka.BootstrapServers == "" {
+			return kube.NewError(kube.InvalidSpec, "broker %s of type %s must set kafka.bootstrapServers", broker.Name, orchestratorv1alpha1.KafkaBroker)
+		}
+	}
+	return nil
+}
+
+func brokerLabels(broker string) map[string]string {
+	labels := kube.AddLabel()
+	labels[BrokerLabelKey] = broker
+	return labels
+}
+
+func isKafkaBroker(broker orchestratorv1alpha1.Broker) bool {
+	return broker.Type == orchestratorv1alpha1.KafkaBroker
+}
+
+func brokerClass(broker orchestratorv1alpha1.Broker) string {
+	if broker.Type == orchestratorv1alpha1.KafkaBroker {
+		return kafkaBrokerClass
+	}
+	return inMemoryBrokerClass
+}
+
+// getBrokerConfig returns the channel configuration of the broker, defaulting
+// the Kafka topics to the values of Knative Eventing.
+func getBrokerConfig(broker orchestratorv1alpha1.Broker) map[string]string {
+	if broker.Type != orchestratorv1alpha1.KafkaBroker {
+		return map[string]string{brokerChannelTemplateKey: inMemoryChannelTemplate}
+	}
+	kafka := broker.Kafka
+	partitions, replicationFactor := kafka.NumPartitions, kafka.ReplicationFactor
+	if partitions == 0 {
+		partitions = kafkaDefaultPartitions
+	}
+	if replicationFactor == 0 {
+		replicationFactor = kafkaDefaultReplicationFactor
+	}
+	data := map[string]string{
+		kafkaBootstrapServersKey: kafka.BootstrapServers,
+		kafkaTopicPartitionsKey:  strconv.Itoa(int(partitions)),
+		kafkaTopicReplicationKey: strconv.Itoa(int(replicationFactor)),
+	}
+	if kafka.AuthSecret != "" {
+		data[kafkaAuthSecretNameKey] = kafka.AuthSecret
+	}
+	return data
+}
+
+// getBroker returns the broker, referencing its ConfigMap.
+func getBroker(broker orchestratorv1alpha1.Broker) *unstructured.Unstructured {
+	desired := newBroker()
+	desired.SetName(broker.Name)
+	desired.SetNamespace(SonataFlowNamespace)
+	desired.SetLabels(brokerLabels(broker.Name))
+	desired.SetAnnotations(map[string]string{brokerClassAnnotation: brokerClass(broker)})
+	spec := map[string]interface{}{
+		"config": map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"name":       broker.Name + BrokerConfigSuffix,
+			"namespace":  SonataFlowNamespace,
+		},
+	}
+	if delivery := broker.Delivery; delivery != nil {
+		options := map[string]interface{}{}
+		if delivery.Retry != nil {
+			options["retry"] = int64(*delivery.Retry)
+		}
+		if delivery.BackoffPolicy != "" {
+			options["backoffPolicy"] = delivery.BackoffPolicy
+		}
+		if delivery.BackoffDelay != "" {
+			options["backoffDelay"] = delivery.BackoffDelay
+		}
+		spec["delivery"] = options
+	}
+	desired.Object["spec"] = spec
+	return desired
+}
+
+// getTrigger returns the trigger of the broker, subscribing the Service of
+// the workflow. Without attributes, the trigger delivers all the events of
+// the broker.
+func getTrigger(broker string, trigger orchestratorv1alpha1.BrokerTrigger) *unstructured.Unstructured {
+	desired := newTrigger()
+	desired.SetName(trigger.Name)
+	desired.SetNamespace(SonataFlowNamespace)
+	desired.SetLabels(brokerLabels(broker))
+	filter := map[string]interface{}{}
+	if len(trigger.Filter) > 0 {
+		attributes := map[string]interface{}{}
+		for name, value := range trigger.Filter {
+			attributes[name] = value
+		}
+		filter["attributes"] = attributes
+	}
+	desired.Object["spec"] = map[string]interface{}{
+		"broker": broker,
+		"filter": filter,
+		"subscriber": map[string]interface{}{
+			"ref": map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"name":       trigger.Workflow,
+				"namespace":  SonataFlowNamespace,
+			},
+		},
+	}
+	return desired
+}
+
+// handleBrokerConfig creates or updates the ConfigMap of the broker.
+func handleBrokerConfig(ctx context.Context, c client.Client, broker orchestratorv1alpha1.Broker) error {
+	logger := log.FromContext(ctx)
+	name := broker.Name + BrokerConfigSuffix
+	data := getBrokerConfig(broker)
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: SonataFlowNamespace, Name: name}, configMap)
+	if apierrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: SonataFlowNamespace,
+				Labels:    brokerLabels(broker.Name),
+			},
+			Data: data,
+		}
+		if err := c.Create(ctx, configMap); err != nil {
+			logger.Error(err, "Error occurred when creating ConfigMap", "CM", name)
+			kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create ConfigMap %s/%s: %v", SonataFlowNamespace, name, err)
+			return err
+		}
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created ConfigMap %s/%s", SonataFlowNamespace, name)
+		return nil
+	}
+	if err != nil {
+		logger.Error(err, "Error occurred when checking ConfigMap exist", "CM", name)
+		return err
+	}
+	if maps.Equal(configMap.Data, data) {
+		return nil
+	}
+	configMap.Data = data
+	if err := c.Update(ctx, configMap); err != nil {
+		logger.Error(err, "Error occurred when updating ConfigMap", "CM", name)
+		kube.EventsFromContext(ctx).Warning(kube.ReasonResourceUpdateFailed, "Failed to update ConfigMap %s/%s: %v", SonataFlowNamespace, name, err)
+		return err
+	}
+	kube.EventsFromContext(ctx).Normal(kube.ReasonResourceUpdated, "Updated ConfigMap %s/%s", SonataFlowNamespace, name)
+	return nil
+}
+
+// handleBroker creates or updates the broker. Its class can't be changed, the
+// broker is recreated when its type changes.
+func handleBroker(ctx context.Context, c client.Client, desired *unstructured.Unstructured) error {
+	return handleEventingObject(ctx, c, desired, func(current *unstructured.Unstructured) bool {
+		return current.GetAnnotations()[brokerClassAnnotation] != desired.GetAnnotations()[brokerClassAnnotation]
+	})
+}
+
+// handleTrigger creates or updates the trigger. Its broker can't be changed,
+// the trigger is recreated when it moves to another broker.
+func handleTrigger(ctx context.Context, c client.Client, desired *unstructured.Unstructured) error {
+	return handleEventingObject(ctx, c, desired, func(current *unstructured.Unstructured) bool {
+		broker, _, _ := unstructured.NestedString(current.Object, "spec", "broker")
+		desiredBroker, _, _ := unstructured.NestedString(desired.Object, "spec", "broker")
+		return broker != desiredBroker
+	})
+}
+
+// eventingOwnedFields are the fields of the spec of the brokers and triggers
+// owned by the orchestrator, removed when the desired spec doesn't set them.
+var eventingOwnedFields = []string{"config", "delivery"}
+
+// handleEventingObject creates or updates the broker or trigger, recreating
+// it when its immutable fields changed. The fields of the spec that are
+// neither declared nor in eventingOwnedFields are kept.
+func handleEventingObject(
+	ctx context.Context,
+	c client.Client,
+	desired *unstructured.Unstructured,
+	immutableChanged func(current *unstructured.Unstructured) bool) error {
+	logger := log.FromContext(ctx)
+	kind := desired.GetKind()
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(desired.GroupVersionKind())
+	err := c.Get(ctx, client.ObjectKeyFromObject(desired), current)
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "Error occurred when retrieving "+kind, kind, desired.GetName())
+		return err
+	}
+	found := err == nil
+	if found && immutableChanged(current) {
+		if err := c.Delete(ctx, current); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "Error occurred when deleting "+kind, kind, current.GetName())
+			kube.EventsFromContext(ctx).Warning(kube.ReasonCleanupFailed, "Failed to delete %s %s/%s: %v",
+				kind, current.GetNamespace(), current.GetName(), err)
+			return err
+		}
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceDeleted, "Deleted %s %s/%s to change an immutable field",
+			kind, current.GetNamespace(), current.GetName())
+		found = false
+	}
+	if !found {
+		if err := c.Create(ctx, desired); err != nil {
+			logger.Error(err, "Error occurred when creating "+kind, kind, desired.GetName())
+			kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create %s %s/%s: %v",
+				kind, desired.GetNamespace(), desired.GetName(), err)
+			return err
+		}
+		logger.Info("Successfully created "+kind, kind, desired.GetName())
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created %s %s/%s", kind, desired.GetNamespace(), desired.GetName())
+		return nil
+	}
+
+	spec, _, _ := unstructured.NestedMap(current.Object, "spec")
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	desiredSpec := desired.Object["spec"].(map[string]interface{})
+	updated := maps.Clone(spec)
+	maps.Copy(updated, desiredSpec)
+	for _, field := range eventingOwnedFields {
+		if _, declared := desiredSpec[field]; !declared {
+			delete(updated, field)
+		}
+	}
+	if equality.Semantic.DeepEqual(spec, updated) {
+		return nil
+	}
+	current.Object["spec"] = updated
+	if err := c.Update(ctx, current); err != nil {
+		logger.Error(err, "Error occurred when updating "+kind, kind, desired.GetName())
+		kube.EventsFromContext(ctx).Warning(kube.ReasonResourceUpdateFailed, "Failed to update %s %s/%s: %v",
+			kind, desired.GetNamespace(), desired.GetName(), err)
+		return err
+	}
+	kube.EventsFromContext(ctx).Normal(kube.ReasonResourceUpdated, "Updated %s %s/%s", kind, desired.GetNamespace(), desired.GetName())
+	return nil
+}
+
+// pruneBrokers deletes the brokers and triggers created by the orchestrator
+// that are no longer declared, with the ConfigMaps of the brokers.
+func pruneBrokers(ctx context.Context, c client.Client, brokers []orchestratorv1alpha1.Broker) error {
+	declared := map[string]bool{}
+	// the broker of each declared trigger
+	triggers := map[string]string{}
+	for _, broker := range brokers {
+		declared[broker.Name] = true
+		for _, trigger := range broker.Triggers {
+			triggers[trigger.Name] = broker.Name
+		}
+	}
+	lists := []client.ObjectList{newTriggerList(), newBrokerList(), &corev1.ConfigMapList{}}
+	for _, list := range lists {
+		if err := c.List(ctx, list, client.InNamespace(SonataFlowNamespace),
+			client.MatchingLabels(kube.AddLabel()), client.HasLabels{BrokerLabelKey}); err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		objects, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range objects {
+			object := item.(client.Object)
+			broker := object.GetLabels()[BrokerLabelKey]
+			if object.GetObjectKind().GroupVersionKind().Kind == KnativeTriggerKind {
+				if triggers[object.GetName()] == broker {
+					continue
+				}
+			} else if declared[broker] {
+				continue
+			}
+			if err := c.Delete(ctx, object); err != nil && !apierrors.IsNotFound(err) {
+				log.FromContext(ctx).Error(err, "Error occurred when deleting broker resource", "Name", object.GetName())
+				return err
+			}
+			if eventing, isEventing := object.(*unstructured.Unstructured); isEventing {
+				kube.EventsFromContext(ctx).Normal(kube.ReasonResourceDeleted, "Deleted %s %s/%s", eventing.GetKind(), object.GetNamespace(), object.GetName())
+			}
+		}
+	}
+	return nil
+}
+
+// checkKnativeEventingReady returns a MissingPrerequisite error until the
+// Knative Eventing instance is ready to reconcile the brokers.
+func checkKnativeEventingReady(ctx context.Context, c client.Client) error {
+	eventing := &knative.KnativeEventing{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: KnativeEventingNamespacedName, Name: KnativeEventingNamespacedName}, eventing)
+	if apierrors.IsNotFound(err) {
+		return kube.NewError(kube.MissingPrerequisite, "%s %s/%s not found", KnativeEventingKind, KnativeEventingNamespacedName, KnativeEventingNamespacedName)
+	}
+	if err != nil {
+		return kube.WrapError(kube.TransientAPI, err)
+	}
+	if !eventing.Status.IsReady() {
+		return kube.NewError(kube.MissingPrerequisite, "%s %s/%s is not ready", KnativeEventingKind, KnativeEventingNamespacedName, KnativeEventingNamespacedName)
+	}
+	return nil
+}
+
+// brokersComponent creates the Knative Eventing brokers declared in the spec
+// and their triggers in the SonataFlow namespace, once Knative Eventing is
+// ready. The Kafka brokers also wait for Knative Kafka.
+type brokersComponent struct{}
+
+func (c *brokersComponent) Name() string { return metrics.ComponentBrokers }
+
+func (c *brokersComponent) ConditionType() string { return TypeBrokersReady }
+
+func (c *brokersComponent) DependsOn() []string { return []string{metrics.ComponentKnative} }
+
+// Enabled returns whether brokers are declared with Knative enabled. The
+// brokers created before are pruned otherwise.
+func (c *brokersComponent) Enabled(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.ServerlessOperator.Enabled && len(spec.ServerlessOperator.Brokers) > 0
+}
+
+func (c *brokersComponent) Paused(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.ServerlessOperator.Paused
+}
+
+func (c *brokersComponent) Prerequisites(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	if err := validateBrokers(spec.ServerlessOperator.Brokers); err != nil {
+		return err
+	}
+	return ensureNamespace(ctx, env, SonataFlowNamespace)
+}
+
+func (c *brokersComponent) Install(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) error {
+	return nil
+}
+
+func (c *brokersComponent) Reconcile(
+	ctx context.Context,
+	env ComponentEnv,
+	spec orchestratorv1alpha1.OrchestratorSpec) (ComponentResult, error) {
+	if err := checkKnativeEventingReady(ctx, env.Client); err != nil {
+		return ComponentResult{}, err
+	}
+	apis := brokerAPIs
+	if slices.ContainsFunc(spec.ServerlessOperator.Brokers, isKafkaBroker) {
+		apis = append(slices.Clone(brokerAPIs), kafkaBrokerAPIs...)
+	}
+	if err := checkAPIs(ctx, env, c.Name(), apis); err != nil {
+		return ComponentResult{}, err
+	}
+	for _, broker := range spec.ServerlessOperator.Brokers {
+		if err := handleBrokerConfig(ctx, env.Client, broker); err != nil {
+			return ComponentResult{}, err
+		}
+		if err := handleBroker(ctx, env.Client, getBroker(broker)); err != nil {
+			return ComponentResult{}, err
+		}
+		for _, trigger := range broker.Triggers {
+			if err := handleTrigger(ctx, env.Client, getTrigger(broker.Name, trigger)); err != nil {
+				return ComponentResult{}, err
+			}
+		}
+	}
+	return ComponentResult{}, pruneBrokers(ctx, env.Client, spec.ServerlessOperator.Brokers)
+}
+
+func (c *brokersComponent) Status(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) (ComponentStatus, error) {
+	return ComponentStatus{Phase: metrics.PhaseInstalled}, nil
+}
+
+func (c *brokersComponent) Cleanup(ctx context.Context, env ComponentEnv, _ orchestratorv1alpha1.OrchestratorSpec) error {
+	return pruneBrokers(ctx, env.Client, nil)
+}
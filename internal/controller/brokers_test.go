@@ -0,0 +1,271 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	knative "knative.dev/operator/pkg/apis/operator/v1beta1"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Brokers", func() {
+	ctx := context.Background()
+	var env ComponentEnv
+	var eventing *knative.KnativeEventing
+	var orchestrator *orchestratorv1alpha1.Orchestrator
+
+	// newEnv returns a client serving the given Knative Eventing APIs, which
+	// are not in the scheme
+	newEnv := func(served ...schema.GroupVersionKind) {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(knative.AddToScheme(scheme)).To(Succeed())
+		eventingMapper := meta.NewDefaultRESTMapper(nil)
+		for _, gvk := range served {
+			eventingMapper.Add(gvk, meta.RESTScopeNamespace)
+		}
+		eventing = &knative.KnativeEventing{
+			ObjectMeta: metav1.ObjectMeta{Namespace: KnativeEventingNamespacedName, Name: KnativeEventingNamespacedName},
+		}
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithRESTMapper(meta.MultiRESTMapper{testrestmapper.TestOnlyStaticRESTMapper(scheme), eventingMapper}).
+			WithObjects(eventing).
+			WithStatusSubresource(eventing).
+			Build()
+		env = ComponentEnv{Client: k8sClient}
+	}
+
+	BeforeEach(func() {
+		newEnv(append(slices.Clone(brokerAPIs), kafkaBrokerAPIs...)...)
+
+		orchestrator = &orchestratorv1alpha1.Orchestrator{}
+		orchestrator.Spec.ServerlessOperator.Enabled = true
+		orchestrator.Spec.ServerlessOperator.Brokers = []orchestratorv1alpha1.Broker{
+			{Name: "default", Delivery: &orchestratorv1alpha1.BrokerDelivery{Retry: util.MakePointer(int32(5)), BackoffPolicy: "exponential"}},
+			{Name: "kafka", Type: orchestratorv1alpha1.KafkaBroker, Kafka: &orchestratorv1alpha1.KafkaChannel{
+				BootstrapServers: "my-cluster-kafka-bootstrap.kafka:9092",
+				NumPartitions:    3,
+				AuthSecret:       "kafka-credentials",
+			}},
+		}
+	})
+
+	markEventingReady := func() {
+		eventing.Status.MarkInstallSucceeded()
+		eventing.Status.MarkDeploymentsAvailable()
+		eventing.Status.MarkDependenciesInstalled()
+		eventing.Status.MarkVersionMigrationEligible()
+		Expect(env.Status().Update(ctx, eventing)).To(Succeed())
+		Expect(eventing.Status.GetCondition(apis.ConditionReady).IsTrue()).To(BeTrue())
+	}
+
+	getBrokerObject := func(name string) (*unstructured.Unstructured, error) {
+		broker := newBroker()
+		return broker, env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: name}, broker)
+	}
+
+	getTriggerObject := func(name string) (*unstructured.Unstructured, error) {
+		trigger := newTrigger()
+		return trigger, env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: name}, trigger)
+	}
+
+	It("should reject the Kafka brokers without their bootstrap servers", func() {
+		component := &brokersComponent{}
+		orchestrator.Spec.ServerlessOperator.Brokers[1].Kafka.BootstrapServers = ""
+		Expect(kube.IsTerminal(component.Prerequisites(ctx, env, orchestrator.Spec))).To(BeTrue())
+
+		orchestrator.Spec.ServerlessOperator.Brokers[1].Type = orchestratorv1alpha1.InMemoryBroker
+		Expect(component.Prerequisites(ctx, env, orchestrator.Spec)).To(MatchError(ContainSubstring("sets kafka")))
+	})
+
+	It("should reject the triggers declared more than once", func() {
+		component := &brokersComponent{}
+		orchestrator.Spec.ServerlessOperator.Brokers[0].Triggers = []orchestratorv1alpha1.BrokerTrigger{{Name: "events", Workflow: "greeting"}}
+		orchestrator.Spec.ServerlessOperator.Brokers[1].Triggers = []orchestratorv1alpha1.BrokerTrigger{{Name: "events", Workflow: "audit"}}
+		err := component.Prerequisites(ctx, env, orchestrator.Spec)
+		Expect(kube.IsTerminal(err)).To(BeTrue())
+		Expect(err).To(MatchError("trigger events is declared more than once"))
+	})
+
+	It("should wait for Knative Kafka before creating the Kafka brokers", func() {
+		component := &brokersComponent{}
+		newEnv(brokerAPIs...)
+		markEventingReady()
+		_, err := component.Reconcile(ctx, env, orchestrator.Spec)
+		missing := &missingAPIsError{}
+		Expect(errors.As(err, &missing)).To(BeTrue())
+		Expect(missing.apis).To(ConsistOf("KafkaSink.eventing.knative.dev/v1alpha1"))
+		_, err = getBrokerObject("kafka")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		orchestrator.Spec.ServerlessOperator.Brokers = orchestrator.Spec.ServerlessOperator.Brokers[:1]
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+		_, err = getBrokerObject("default")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should subscribe the workflows to their brokers and prune the undeclared triggers", func() {
+		component := &brokersComponent{}
+		markEventingReady()
+		orchestrator.Spec.ServerlessOperator.Brokers[0].Triggers = []orchestratorv1alpha1.BrokerTrigger{
+			{Name: "greeting-events", Workflow: "greeting", Filter: map[string]string{"type": "org.acme.greeting"}},
+			{Name: "audit-events", Workflow: "audit"},
+		}
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+
+		trigger, err := getTriggerObject("greeting-events")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trigger.GetLabels()).To(HaveKeyWithValue(BrokerLabelKey, "default"))
+		Expect(trigger.Object["spec"]).To(Equal(map[string]interface{}{
+			"broker": "default",
+			"filter": map[string]interface{}{"attributes": map[string]interface{}{"type": "org.acme.greeting"}},
+			"subscriber": map[string]interface{}{"ref": map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"name":       "greeting",
+				"namespace":  SonataFlowNamespace,
+			}},
+		}))
+		trigger, err = getTriggerObject("audit-events")
+		Expect(err).NotTo(HaveOccurred())
+		filter, _, _ := unstructured.NestedMap(trigger.Object, "spec", "filter")
+		Expect(filter).To(BeEmpty())
+
+		// the broker of a trigger can't be changed, the trigger is recreated
+		orchestrator.Spec.ServerlessOperator.Brokers[1].Triggers = orchestrator.Spec.ServerlessOperator.Brokers[0].Triggers[:1]
+		orchestrator.Spec.ServerlessOperator.Brokers[0].Triggers = nil
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+		trigger, err = getTriggerObject("greeting-events")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trigger.GetLabels()).To(HaveKeyWithValue(BrokerLabelKey, "kafka"))
+		broker, _, _ := unstructured.NestedString(trigger.Object, "spec", "broker")
+		Expect(broker).To(Equal("kafka"))
+		_, err = getTriggerObject("audit-events")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		Expect(component.Cleanup(ctx, env, orchestrator.Spec)).To(Succeed())
+		_, err = getTriggerObject("greeting-events")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should wait for Knative Eventing to be ready", func() {
+		component := &brokersComponent{}
+		_, err := component.Reconcile(ctx, env, orchestrator.Spec)
+		Expect(kube.ErrorKindOf(err)).To(Equal(kube.MissingPrerequisite))
+		_, err = getBrokerObject("default")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should create the declared brokers with their channel configuration", func() {
+		component := &brokersComponent{}
+		markEventingReady()
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+
+		broker, err := getBrokerObject("default")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(broker.GetLabels()).To(HaveKeyWithValue(BrokerLabelKey, "default"))
+		Expect(broker.GetAnnotations()).To(HaveKeyWithValue(brokerClassAnnotation, inMemoryBrokerClass))
+		configName, _, _ := unstructured.NestedString(broker.Object, "spec", "config", "name")
+		Expect(configName).To(Equal("default" + BrokerConfigSuffix))
+		retry, _, _ := unstructured.NestedInt64(broker.Object, "spec", "delivery", "retry")
+		Expect(retry).To(Equal(int64(5)))
+		config := &corev1.ConfigMap{}
+		Expect(env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: configName}, config)).To(Succeed())
+		Expect(config.Data).To(HaveKeyWithValue(brokerChannelTemplateKey, ContainSubstring("InMemoryChannel")))
+
+		broker, err = getBrokerObject("kafka")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(broker.GetAnnotations()).To(HaveKeyWithValue(brokerClassAnnotation, kafkaBrokerClass))
+		Expect(env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "kafka" + BrokerConfigSuffix}, config)).To(Succeed())
+		Expect(config.Data).To(Equal(map[string]string{
+			kafkaBootstrapServersKey: "my-cluster-kafka-bootstrap.kafka:9092",
+			kafkaTopicPartitionsKey:  "3",
+			kafkaTopicReplicationKey: "3",
+			kafkaAuthSecretNameKey:   "kafka-credentials",
+		}))
+	})
+
+	It("should clear the delivery removed from the spec", func() {
+		component := &brokersComponent{}
+		markEventingReady()
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+
+		orchestrator.Spec.ServerlessOperator.Brokers[0].Delivery = nil
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+		broker, err := getBrokerObject("default")
+		Expect(err).NotTo(HaveOccurred())
+		_, found, _ := unstructured.NestedMap(broker.Object, "spec", "delivery")
+		Expect(found).To(BeFalse())
+		// the config is still set
+		_, found, _ = unstructured.NestedMap(broker.Object, "spec", "config")
+		Expect(found).To(BeTrue())
+	})
+
+	It("should recreate the broker whose type changed and prune the undeclared ones", func() {
+		component := &brokersComponent{}
+		markEventingReady()
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+
+		orchestrator.Spec.ServerlessOperator.Brokers = orchestrator.Spec.ServerlessOperator.Brokers[1:]
+		orchestrator.Spec.ServerlessOperator.Brokers[0].Type = orchestratorv1alpha1.InMemoryBroker
+		orchestrator.Spec.ServerlessOperator.Brokers[0].Kafka = nil
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+
+		broker, err := getBrokerObject("kafka")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(broker.GetAnnotations()).To(HaveKeyWithValue(brokerClassAnnotation, inMemoryBrokerClass))
+		_, err = getBrokerObject("default")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		err = env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "default" + BrokerConfigSuffix}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		Expect(component.Cleanup(ctx, env, orchestrator.Spec)).To(Succeed())
+		_, err = getBrokerObject("kafka")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should reference the first declared broker from the platform", func() {
+		spec := getSonataFlowPlatformSpec(orchestrator.Spec)
+		Expect(spec.Eventing.Broker.Ref.Name).To(Equal("default"))
+		Expect(spec.Eventing.Broker.Ref.Namespace).To(Equal(SonataFlowNamespace))
+
+		orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform.Eventing.Broker = &orchestratorv1alpha1.BrokerReference{Name: "shared", Namespace: "eventing"}
+		Expect(getSonataFlowPlatformSpec(orchestrator.Spec).Eventing.Broker.Ref.Name).To(Equal("shared"))
+
+		orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform.Eventing.Broker = nil
+		orchestrator.Spec.ServerlessOperator.Enabled = false
+		Expect(getSonataFlowPlatformSpec(orchestrator.Spec).Eventing).To(BeNil())
+	})
+})
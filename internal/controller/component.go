@@ -0,0 +1,226 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	olmclientset "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ComponentEnv gives the components access to the cluster.
+type ComponentEnv struct {
+	client.Client
+	OLMClient olmclientset.Interface
+}
+
+// ComponentStatus is the install status of a component.
+type ComponentStatus struct {
+	// Phase is one of the install phases of the metrics package.
+	Phase string
+	// Subscription and CSV of the operator of the component, if any.
+	Subscription *operatorsv1alpha1.Subscription
+	CSV          *operatorsv1alpha1.ClusterServiceVersion
+}
+
+// ComponentResult is the outcome of the reconciliation of a component.
+type ComponentResult struct {
+	// Conditions are set on the orchestrator status besides the Ready
+	// condition of the component, once all the components are reconciled.
+	Conditions []metav1.Condition
+}
+
+// Component is a subsystem managed by the orchestrator. The reconciler drives
+// every registered component through the same steps: a disabled component is
+// cleaned up, an enabled one has its prerequisites checked, its operator
+// installed and its resources reconciled.
+type Component interface {
+	// Name identifies the component in the dependency graph, the events and
+	// the metrics.
+	Name() string
+	// ConditionType is the type of the Ready condition of the component.
+	ConditionType() string
+	// DependsOn lists the components that must be ready before this one is
+	// reconciled.
+	DependsOn() []string
+	// Enabled returns whether the spec enables the component.
+	Enabled(spec orchestratorv1alpha1.OrchestratorSpec) bool
+	// Paused returns whether the spec pauses the component: it is neither
+	// installed, updated nor cleaned up, its health is only observed.
+	Paused(spec orchestratorv1alpha1.OrchestratorSpec) bool
+	// Prerequisites checks what the component needs before being installed,
+	// such as namespaces, secrets or served APIs.
+	Prerequisites(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error
+	// Install installs the operator of the component.
+	Install(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error
+	// Reconcile creates or updates the resources of the component. The
+	// components are reconciled concurrently, so they report their conditions
+	// in the result instead of setting them on the orchestrator.
+	Reconcile(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) (ComponentResult, error)
+	// Status returns the install status of the component.
+	Status(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) (ComponentStatus, error)
+	// Cleanup removes the resources of the component when it is disabled or
+	// the orchestrator is deleted.
+	Cleanup(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error
+}
+
+// ComponentRegistry holds the components reconciled by the orchestrator, in
+// the order they are reported. They are cleaned up in the reverse order.
+type ComponentRegistry struct {
+	components []Component
+}
+
+// DefaultComponents returns the registry of the components managed by the
+// orchestrator.
+func DefaultComponents() *ComponentRegistry {
+	return &ComponentRegistry{components: []Component{
+		&postgreSQLComponent{},
+		&knativeComponent{},
+		&brokersComponent{},
+		&sonataFlowComponent{},
+		&workflowsComponent{},
+		&backstageComponent{},
+	}}
+}
+
+// Register adds a component to the registry.
+func (r *ComponentRegistry) Register(component Component) error {
+	for _, registered := range r.components {
+		if registered.Name() == component.Name() {
+			return fmt.Errorf("component %s is already registered", component.Name())
+		}
+	}
+	r.components = append(r.components, component)
+	return nil
+}
+
+// Components returns the registered components.
+func (r *ComponentRegistry) Components() []Component {
+	return r.components
+}
+
+// reconcileComponent drives the component through its steps.
+func reconcileComponent(
+	ctx context.Context,
+	env ComponentEnv,
+	component Component,
+	spec orchestratorv1alpha1.OrchestratorSpec) (ComponentResult, error) {
+	logger := log.FromContext(ctx).WithValues("Component", component.Name())
+	ctx = log.IntoContext(ctx, logger)
+
+	if !component.Enabled(spec) {
+		return ComponentResult{}, component.Cleanup(ctx, env, spec)
+	}
+	logger.Info("Starting reconciliation of component")
+	if err := component.Prerequisites(ctx, env, spec); err != nil {
+		return ComponentResult{}, err
+	}
+	if err := component.Install(ctx, env, spec); err != nil {
+		return ComponentResult{}, err
+	}
+	result, err := component.Reconcile(ctx, env, spec)
+	if err != nil {
+		return result, err
+	}
+	logger.Info("Successfully reconciled component")
+	return result, nil
+}
+
+// ensureNamespace creates the namespace if it does not exist.
+func ensureNamespace(ctx context.Context, env ComponentEnv, namespace string) error {
+	logger := log.FromContext(ctx)
+	_, err := kube.CheckNamespaceExist(ctx, env.Client, namespace)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		logger.Error(err, "Error occurred when checking namespace exists", "NS", namespace)
+		return err
+	}
+	logger.Info("Creating namespace", "NS", namespace)
+	if err := kube.CreateNamespace(ctx, env.Client, namespace); err != nil {
+		logger.Error(err, "Error occurred when creating namespace", "NS", namespace)
+		return err
+	}
+	return nil
+}
+
+// installOperator subscribes to the operator of the component unless the
+// subscription already exists.
+func installOperator(
+	ctx context.Context,
+	env ComponentEnv,
+	component string,
+	operatorGroup string,
+	subscription orchestratorv1alpha1.Subscription) error {
+	logger := log.FromContext(ctx)
+	if err := checkAPIs(ctx, env, component, olmAPIs); err != nil {
+		return err
+	}
+
+	subscriptionExists, installedSubscription, err := kube.CheckSubscriptionExists(ctx, env.OLMClient, subscription.Namespace, subscription.Name)
+	if err != nil {
+		logger.Error(err, "Error occurred when checking subscription exists", "SubscriptionName", subscription.Name)
+		return err
+	}
+	if subscriptionExists {
+		// follow a change of catalog source, e.g. to the air-gapped mirror
+		return kube.UpdateSubscriptionSource(ctx, env.OLMClient, installedSubscription, subscription)
+	}
+	if err := kube.InstallOperatorViaSubscription(ctx, env.Client, env.OLMClient, operatorGroup, subscription); err != nil {
+		logger.Error(err, "Error occurred when installing operator", "SubscriptionName", subscription.Name)
+		return err
+	}
+	logger.Info("Operator successfully installed via Subscription", "SubscriptionName", subscription.Name)
+	return nil
+}
+
+// subscriptionStatus returns the install status of an operator from its
+// subscription and CSV.
+func subscriptionStatus(
+	ctx context.Context,
+	env ComponentEnv,
+	subscription orchestratorv1alpha1.Subscription) (ComponentStatus, error) {
+	status := ComponentStatus{Phase: metrics.PhaseInstalling}
+	subscriptionExists, installedSubscription, err := kube.CheckSubscriptionExists(ctx, env.OLMClient, subscription.Namespace, subscription.Name)
+	if err != nil || !subscriptionExists {
+		return status, err
+	}
+	status.Subscription = installedSubscription
+
+	csv, err := kube.GetInstalledCSV(ctx, env.OLMClient, installedSubscription)
+	if err != nil || csv == nil {
+		return status, err
+	}
+	status.CSV = csv
+	switch csv.Status.Phase {
+	case operatorsv1alpha1.CSVPhaseSucceeded:
+		status.Phase = metrics.PhaseInstalled
+	case operatorsv1alpha1.CSVPhaseFailed:
+		status.Phase = metrics.PhaseFailed
+	}
+	return status, nil
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	olmfake "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned/fake"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeComponent records the steps it goes through.
+type fakeComponent struct {
+	name             string
+	enabled          bool
+	paused           bool
+	prerequisitesErr error
+	// created is created by Reconcile, if set
+	created client.Object
+	// conditions are returned by Reconcile
+	conditions []metav1.Condition
+	steps      []string
+}
+
+func (c *fakeComponent) Name() string          { return c.name }
+func (c *fakeComponent) ConditionType() string { return c.name + "Ready" }
+func (c *fakeComponent) DependsOn() []string   { return nil }
+
+func (c *fakeComponent) Enabled(orchestratorv1alpha1.OrchestratorSpec) bool { return c.enabled }
+func (c *fakeComponent) Paused(orchestratorv1alpha1.OrchestratorSpec) bool  { return c.paused }
+
+func (c *fakeComponent) Prerequisites(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) error {
+	c.steps = append(c.steps, "prerequisites")
+	return c.prerequisitesErr
+}
+
+func (c *fakeComponent) Install(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) error {
+	c.steps = append(c.steps, "install")
+	return nil
+}
+
+func (c *fakeComponent) Reconcile(
+	ctx context.Context,
+	env ComponentEnv,
+	_ orchestratorv1alpha1.OrchestratorSpec) (ComponentResult, error) {
+	c.steps = append(c.steps, "reconcile")
+	result := ComponentResult{Conditions: c.conditions}
+	if c.created != nil {
+		return result, env.Create(ctx, c.created)
+	}
+	return result, nil
+}
+
+func (c *fakeComponent) Status(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) (ComponentStatus, error) {
+	return ComponentStatus{Phase: metrics.PhaseInstalled}, nil
+}
+
+func (c *fakeComponent) Cleanup(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) error {
+	c.steps = append(c.steps, "cleanup")
+	return nil
+}
+
+var _ = Describe("Components", func() {
+	ctx := context.Background()
+	orchestrator := &orchestratorv1alpha1.Orchestrator{}
+
+	It("should reject a component registered twice", func() {
+		registry := &ComponentRegistry{}
+		Expect(registry.Register(&fakeComponent{name: "tekton"})).To(Succeed())
+		Expect(registry.Register(&fakeComponent{name: "tekton"})).To(MatchError(ContainSubstring("already registered")))
+		Expect(registry.Components()).To(HaveLen(1))
+	})
+
+	It("should order the default components along their dependencies", func() {
+		nodes := []componentNode{}
+		for _, component := range DefaultComponents().Components() {
+			nodes = append(nodes, componentNode{name: component.Name(), dependsOn: component.DependsOn()})
+		}
+		Expect(validateGraph(nodes)).To(Succeed())
+	})
+
+	It("should drive an enabled component through its steps", func() {
+		component := &fakeComponent{name: "tekton", enabled: true}
+		Expect(reconcileComponent(ctx, ComponentEnv{}, component, orchestrator.Spec)).Error().To(Succeed())
+		Expect(component.steps).To(Equal([]string{"prerequisites", "install", "reconcile"}))
+	})
+
+	It("should not install a component missing its prerequisites", func() {
+		failure := errors.New("missing namespace")
+		component := &fakeComponent{name: "tekton", enabled: true, prerequisitesErr: failure}
+		Expect(reconcileComponent(ctx, ComponentEnv{}, component, orchestrator.Spec)).Error().To(MatchError(failure))
+		Expect(component.steps).To(Equal([]string{"prerequisites"}))
+	})
+
+	It("should only clean up a disabled component", func() {
+		component := &fakeComponent{name: "tekton"}
+		Expect(reconcileComponent(ctx, ComponentEnv{}, component, orchestrator.Spec)).Error().To(Succeed())
+		Expect(component.steps).To(Equal([]string{"cleanup"}))
+	})
+
+	It("should create the namespace of a component", func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		env := ComponentEnv{Client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+
+		Expect(ensureNamespace(ctx, env, "tekton-pipelines")).To(Succeed())
+		namespace := &corev1.Namespace{}
+		Expect(env.Get(ctx, client.ObjectKey{Name: "tekton-pipelines"}, namespace)).To(Succeed())
+		// an existing namespace is left as is
+		Expect(ensureNamespace(ctx, env, "tekton-pipelines")).To(Succeed())
+	})
+
+	It("should only skip the components with a terminal error until the spec changes", func() {
+		failed := &fakeComponent{name: "tekton", enabled: true}
+		healthy := &fakeComponent{name: "argocd", enabled: true}
+		registry := &ComponentRegistry{}
+		Expect(registry.Register(failed)).To(Succeed())
+		Expect(registry.Register(healthy)).To(Succeed())
+		r := &OrchestratorReconciler{Recorder: record.NewFakeRecorder(10), Components: registry}
+
+		orchestrator := &orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+		setCondition(orchestrator, metav1.Condition{
+			Type:    failed.ConditionType(),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(kube.InvalidSpec),
+			Message: "bad spec",
+		})
+		_, err := r.reconcileComponents(ctx, orchestrator)
+		Expect(kube.IsTerminal(err)).To(BeTrue())
+		Expect(failed.steps).To(BeEmpty())
+		Expect(healthy.steps).To(Equal([]string{"prerequisites", "install", "reconcile"}))
+
+		orchestrator.Generation = 2
+		_, err = r.reconcileComponents(ctx, orchestrator)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failed.steps).To(Equal([]string{"prerequisites", "install", "reconcile"}))
+	})
+
+	It("should set the conditions reported by the components", func() {
+		verified := metav1.Condition{Type: TypePluginsVerified, Status: metav1.ConditionTrue, Reason: "IntegrityVerified"}
+		component := &fakeComponent{name: "rhdh", enabled: true, conditions: []metav1.Condition{verified}}
+		registry := &ComponentRegistry{}
+		Expect(registry.Register(component)).To(Succeed())
+		r := &OrchestratorReconciler{Recorder: record.NewFakeRecorder(10), Components: registry}
+
+		orchestrator := &orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+		_, err := r.reconcileComponents(ctx, orchestrator)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(orchestrator.Status.Conditions).To(ContainElement(And(
+			HaveField("Type", TypePluginsVerified),
+			HaveField("Status", metav1.ConditionTrue),
+			HaveField("ObservedGeneration", int64(1)),
+		)))
+	})
+
+	It("should report the missing APIs once", func() {
+		orchestrator := &orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+		recorder := record.NewFakeRecorder(10)
+		ctx := kube.NewEvents(recorder, orchestrator).IntoContext(ctx)
+		missing := &missingAPIsError{component: "knative", apis: []string{"KnativeServing.operator.knative.dev/v1beta1"}}
+		Expect(reportComponentReady(ctx, orchestrator, TypeKnativeReady, true, missing)).To(Succeed())
+		Expect(reportComponentReady(ctx, orchestrator, TypeKnativeReady, true, missing)).To(Succeed())
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(ContainSubstring("APIs KnativeServing.operator.knative.dev/v1beta1 are not served yet"))
+	})
+
+	It("should report a disabled component", func() {
+		orchestrator := &orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+		Expect(reportComponentReady(ctx, orchestrator, TypeKnativeReady, false, nil)).To(Succeed())
+		Expect(orchestrator.Status.Conditions).To(ContainElement(And(
+			HaveField("Type", TypeKnativeReady),
+			HaveField("Status", metav1.ConditionFalse),
+			HaveField("Reason", ReasonDisabled),
+		)))
+	})
+	It("should move an existing subscription to the catalog source of the spec", func() {
+		scheme := runtime.NewScheme()
+		Expect(operatorsv1alpha1.AddToScheme(scheme)).To(Succeed())
+		installed := &operatorsv1alpha1.Subscription{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-serverless", Name: "serverless-operator"},
+			Spec: &operatorsv1alpha1.SubscriptionSpec{
+				CatalogSource:          "redhat-operators",
+				CatalogSourceNamespace: kube.CatalogSourceNamespace,
+				Package:                "serverless-operator",
+			},
+		}
+		env := ComponentEnv{
+			Client: fake.NewClientBuilder().WithScheme(scheme).
+				WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).Build(),
+			OLMClient: olmfake.NewSimpleClientset(installed),
+		}
+		subscription := orchestratorv1alpha1.Subscription{
+			Namespace:       "openshift-serverless",
+			Name:            "serverless-operator",
+			SourceName:      "mirrored-operators",
+			SourceNamespace: "openshift-marketplace",
+		}
+
+		Expect(installOperator(ctx, env, "knative", "serverless-operator-group", subscription)).To(Succeed())
+		updated, err := env.OLMClient.OperatorsV1alpha1().Subscriptions("openshift-serverless").Get(ctx, "serverless-operator", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Spec.CatalogSource).To(Equal("mirrored-operators"))
+		Expect(updated.Spec.Package).To(Equal("serverless-operator"))
+	})
+})
@@ -0,0 +1,345 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	sonataapi "github.com/apache/incubator-kie-kogito-serverless-operator/api/v1alpha08"
+	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	knative "knative.dev/operator/pkg/apis/operator/v1beta1"
+	backstagev1alpha1 "redhat-developer/red-hat-developer-hub-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Severities of the diagnostic findings.
+const (
+	SeverityError   = "Error"
+	SeverityWarning = "Warning"
+	SeverityInfo    = "Info"
+)
+
+// Finding is a known failure pattern found in the collected resources.
+type Finding struct {
+	Severity string
+	// Object is the kind, namespace and name of the resource showing the
+	// failure.
+	Object  string
+	Message string
+	// Hint suggests how to fix the failure, if known.
+	Hint string
+}
+
+// DiagnoseOptions selects what is collected.
+type DiagnoseOptions struct {
+	// Namespace of the orchestrators, all namespaces when empty.
+	Namespace string
+	// OperatorNamespace is the namespace of the orchestrator operator, whose
+	// logs are collected with the ones of the managed operators.
+	OperatorNamespace string
+	// LogLines is the number of lines collected from the end of each log.
+	LogLines int64
+}
+
+// Diagnostics holds the resources and logs collected for a support case.
+type Diagnostics struct {
+	CollectedAt   time.Time
+	Orchestrators []string
+	// Objects are the collected resources, with the values of the secrets
+	// redacted.
+	Objects []*unstructured.Unstructured
+	// Logs are the container logs, keyed by namespace/pod/container.
+	Logs     map[string][]byte
+	Findings []Finding
+	// Errors are the failures to collect some of the resources, which do not
+	// stop the collection.
+	Errors []string
+}
+
+// labeledLists are the kinds of the resources created by the orchestrator
+// and labeled as such.
+func labeledLists() []client.ObjectList {
+	return []client.ObjectList{
+		&corev1.NamespaceList{},
+		&corev1.ConfigMapList{},
+		&corev1.SecretList{},
+		&batchv1.JobList{},
+		&knative.KnativeEventingList{},
+		&knative.KnativeServingList{},
+		newBrokerList(),
+		newTriggerList(),
+		&sonataapi.SonataFlowPlatformList{},
+		&sonataapi.SonataFlowClusterPlatformList{},
+		&sonataapi.SonataFlowList{},
+		&backstagev1alpha1.BackstageList{},
+	}
+}
+
+// Diagnose collects the orchestrators, the resources labeled as created by
+// them, the OLM resources of the operators they subscribe and the logs of the
+// pods of the involved namespaces, then evaluates the known failure patterns.
+// The collection goes on when some of the resources can't be read, the
+// failures are reported in the diagnostics.
+func Diagnose(
+	ctx context.Context,
+	c client.Client,
+	clientset kubernetes.Interface,
+	scheme *runtime.Scheme,
+	options DiagnoseOptions) *Diagnostics {
+	collector := &diagnosticsCollector{
+		client:      c,
+		scheme:      scheme,
+		diagnostics: &Diagnostics{CollectedAt: time.Now().UTC(), Logs: map[string][]byte{}},
+		namespaces:  map[string]bool{},
+	}
+	if options.OperatorNamespace != "" {
+		collector.namespaces[options.OperatorNamespace] = true
+	}
+
+	orchestrators := &orchestratorv1alpha1.OrchestratorList{}
+	if err := c.List(ctx, orchestrators, client.InNamespace(options.Namespace)); err != nil {
+		collector.failed("Orchestrators", err)
+	}
+	for i := range orchestrators.Items {
+		orchestrator := &orchestrators.Items[i]
+		collector.add(orchestrator)
+		collector.diagnostics.Orchestrators = append(collector.diagnostics.Orchestrators, client.ObjectKeyFromObject(orchestrator).String())
+		collector.collectEvents(ctx, orchestrator)
+		for _, subscription := range []orchestratorv1alpha1.Subscription{
+			orchestrator.Spec.ServerlessOperator.Subscription,
+			orchestrator.Spec.SonataFlowOperator.Subscription,
+			orchestrator.Spec.RhdhOperator.Subscription,
+		} {
+			collector.collectSubscription(ctx, subscription)
+		}
+	}
+	for _, list := range labeledLists() {
+		collector.collectList(ctx, list, client.MatchingLabels(kube.AddLabel()))
+	}
+	for _, namespace := range collector.sortedNamespaces() {
+		collector.collectPods(ctx, clientset, namespace, options.LogLines)
+	}
+
+	diagnostics := collector.diagnostics
+	for i, object := range collector.objects {
+		for _, rule := range diagnosisRules {
+			diagnostics.Findings = append(diagnostics.Findings, rule(object, diagnostics.Objects[i])...)
+		}
+	}
+	return diagnostics
+}
+
+type diagnosticsCollector struct {
+	client      client.Client
+	scheme      *runtime.Scheme
+	diagnostics *Diagnostics
+	objects     []client.Object
+	// namespaces are the namespaces of the collected resources, whose pods
+	// are collected.
+	namespaces map[string]bool
+}
+
+// add collects the object unless it was already collected.
+func (c *diagnosticsCollector) add(object client.Object) {
+	for _, collected := range c.objects {
+		if reflect.TypeOf(collected) == reflect.TypeOf(object) && client.ObjectKeyFromObject(collected) == client.ObjectKeyFromObject(object) {
+			return
+		}
+	}
+	manifest, err := toUnstructured(c.scheme, object)
+	if err != nil {
+		c.failed(fmt.Sprintf("%T %s", object, client.ObjectKeyFromObject(object)), err)
+		return
+	}
+	manifest.SetManagedFields(nil)
+	c.objects = append(c.objects, object)
+	c.diagnostics.Objects = append(c.diagnostics.Objects, manifest)
+	if object.GetNamespace() != "" {
+		c.namespaces[object.GetNamespace()] = true
+	}
+	if _, ok := object.(*corev1.Namespace); ok {
+		c.namespaces[object.GetName()] = true
+	}
+}
+
+func (c *diagnosticsCollector) failed(what string, err error) {
+	c.diagnostics.Errors = append(c.diagnostics.Errors, fmt.Sprintf("collecting %s: %v", what, err))
+}
+
+// collectList collects the items of the list. The kinds not served by the
+// cluster are skipped, as their operator may not be installed.
+func (c *diagnosticsCollector) collectList(ctx context.Context, list client.ObjectList, opts ...client.ListOption) {
+	if err := c.client.List(ctx, list, opts...); err != nil {
+		if !meta.IsNoMatchError(err) {
+			c.failed(fmt.Sprintf("%T", list), err)
+		}
+		return
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		c.failed(fmt.Sprintf("%T", list), err)
+		return
+	}
+	for _, item := range items {
+		if object, ok := item.(client.Object); ok {
+			c.add(object)
+		}
+	}
+}
+
+// collect collects the object with the given key, if it exists.
+func (c *diagnosticsCollector) collect(ctx context.Context, key client.ObjectKey, object client.Object) bool {
+	if err := c.client.Get(ctx, key, object); err != nil {
+		if !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			c.failed(fmt.Sprintf("%T %s", object, key), err)
+		}
+		return false
+	}
+	c.add(object)
+	return true
+}
+
+// collectSubscription collects the subscription with its install plan, its
+// CSV and the operator groups of its namespace.
+func (c *diagnosticsCollector) collectSubscription(ctx context.Context, subscription orchestratorv1alpha1.Subscription) {
+	if subscription.Name == "" || subscription.Namespace == "" {
+		return
+	}
+	c.namespaces[subscription.Namespace] = true
+	c.collectList(ctx, &operatorsv1.OperatorGroupList{}, client.InNamespace(subscription.Namespace))
+	installed := &operatorsv1alpha1.Subscription{}
+	if !c.collect(ctx, client.ObjectKey{Namespace: subscription.Namespace, Name: subscription.Name}, installed) {
+		return
+	}
+	if ref := installed.Status.InstallPlanRef; ref != nil {
+		c.collect(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, &operatorsv1alpha1.InstallPlan{})
+	}
+	for _, csv := range []string{installed.Status.InstalledCSV, installed.Status.CurrentCSV} {
+		if csv != "" {
+			c.collect(ctx, client.ObjectKey{Namespace: subscription.Namespace, Name: csv}, &operatorsv1alpha1.ClusterServiceVersion{})
+		}
+	}
+}
+
+// collectEvents collects the events recorded on the orchestrator.
+func (c *diagnosticsCollector) collectEvents(ctx context.Context, orchestrator *orchestratorv1alpha1.Orchestrator) {
+	events := &corev1.EventList{}
+	if err := c.client.List(ctx, events, client.InNamespace(orchestrator.Namespace)); err != nil {
+		c.failed("Events", err)
+		return
+	}
+	for i := range events.Items {
+		involved := events.Items[i].InvolvedObject
+		if involved.Kind == "Orchestrator" && involved.Name == orchestrator.Name {
+			c.add(&events.Items[i])
+		}
+	}
+}
+
+// collectPods collects the pods of the namespace with the end of their logs.
+func (c *diagnosticsCollector) collectPods(ctx context.Context, clientset kubernetes.Interface, namespace string, logLines int64) {
+	pods := &corev1.PodList{}
+	if err := c.client.List(ctx, pods, client.InNamespace(namespace)); err != nil {
+		c.failed("Pods of "+namespace, err)
+		return
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		c.add(pod)
+		for _, container := range pod.Spec.Containers {
+			key := fmt.Sprintf("%s/%s/%s", namespace, pod.Name, container.Name)
+			logs, err := readLogs(ctx, clientset, pod, container.Name, logLines)
+			if err != nil {
+				c.failed("logs of "+key, err)
+				continue
+			}
+			c.diagnostics.Logs[key] = logs
+		}
+	}
+}
+
+func readLogs(ctx context.Context, clientset kubernetes.Interface, pod *corev1.Pod, container string, logLines int64) ([]byte, error) {
+	options := &corev1.PodLogOptions{Container: container}
+	if logLines > 0 {
+		options.TailLines = &logLines
+	}
+	stream, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	return io.ReadAll(stream)
+}
+
+func (c *diagnosticsCollector) sortedNamespaces() []string {
+	namespaces := make([]string, 0, len(c.namespaces))
+	for namespace := range c.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// Summary returns the report of the findings and of the collected resources.
+func (d *Diagnostics) Summary() string {
+	summary := &strings.Builder{}
+	fmt.Fprintf(summary, "Orchestrator diagnostics collected at %s\n\n", d.CollectedAt.Format(time.RFC3339))
+	fmt.Fprintf(summary, "Orchestrators: %s\n", strings.Join(d.Orchestrators, ", "))
+	fmt.Fprintf(summary, "Collected %d resources and %d logs\n\n", len(d.Objects), len(d.Logs))
+
+	findings := slices.Clone(d.Findings)
+	// the errors first, then the warnings and the notes
+	severities := map[string]int{SeverityError: 0, SeverityWarning: 1, SeverityInfo: 2}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severities[findings[i].Severity] < severities[findings[j].Severity]
+	})
+	if len(findings) == 0 {
+		fmt.Fprintln(summary, "No known failure pattern found.")
+	} else {
+		fmt.Fprintf(summary, "Findings (%d):\n", len(findings))
+	}
+	for _, finding := range findings {
+		fmt.Fprintf(summary, "[%s] %s: %s\n", finding.Severity, finding.Object, finding.Message)
+		if finding.Hint != "" {
+			fmt.Fprintf(summary, "  Hint: %s\n", finding.Hint)
+		}
+	}
+	if len(d.Errors) > 0 {
+		fmt.Fprintf(summary, "\nCollection errors (%d):\n", len(d.Errors))
+		for _, err := range d.Errors {
+			fmt.Fprintf(summary, "- %s\n", err)
+		}
+	}
+	return summary.String()
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// diagnosisRule returns the findings of a known failure pattern on a
+// collected object, given with its redacted manifest.
+type diagnosisRule func(object client.Object, manifest *unstructured.Unstructured) []Finding
+
+var diagnosisRules = []diagnosisRule{
+	diagnoseOrchestrator,
+	diagnoseSubscription,
+	diagnoseInstallPlan,
+	diagnoseCSV,
+	diagnosePod,
+	diagnoseJob,
+	diagnoseNotReady,
+}
+
+// hints of the reasons of the Ready conditions of the orchestrator.
+var conditionHints = map[string]string{
+	string(kube.MissingPrerequisite):   "Create the missing resource, the orchestrator reconciles once it exists.",
+	string(kube.InvalidSpec):           "Fix the Orchestrator spec, the reconciliation is not retried until it changes.",
+	string(kube.TransientAPI):          "Check the connectivity to the API server and the registries, the reconciliation is retried.",
+	string(kube.OperatorInstallFailed): "Check the Subscription, InstallPlan and CSV of the operator.",
+	ReasonWaitingForCRD:                "The operator of the component is still installing, check its CSV if this persists.",
+	ReasonDependencyNotReady:           "Fix the component it depends on first.",
+	ReasonPaused:                       "Unset the paused flags of the spec to resume the reconciliation.",
+}
+
+// waitingReasons are the reasons of the containers that won't start without
+// a fix.
+var waitingReasons = map[string]string{
+	"CrashLoopBackOff":           "Check the logs of the container.",
+	"ImagePullBackOff":           "Check that the image is reachable, or mirrored when running air-gapped.",
+	"ErrImagePull":               "Check that the image is reachable, or mirrored when running air-gapped.",
+	"InvalidImageName":           "Check the image reference.",
+	"CreateContainerConfigError": "Check the ConfigMaps and Secrets referenced by the container.",
+}
+
+// readyConditions are the types of the conditions reporting the readiness of
+// the resources created for the components.
+var readyConditions = map[string]string{
+	KnativeEventingKind:           "Ready",
+	KnativeServingKind:            "Ready",
+	KnativeBrokerKind:             "Ready",
+	KnativeTriggerKind:            "Ready",
+	SonataFlowPlatformKind:        "Succeed",
+	SonataFlowClusterPlatformKind: "Succeed",
+	SonataFlowKind:                "Running",
+	"Backstage":                   "Deployed",
+}
+
+func diagnoseOrchestrator(object client.Object, _ *unstructured.Unstructured) []Finding {
+	orchestrator, ok := object.(*orchestratorv1alpha1.Orchestrator)
+	if !ok {
+		return nil
+	}
+	name := objectName("Orchestrator", object)
+	findings := []Finding{}
+	if orchestrator.Spec.DryRun {
+		findings = append(findings, Finding{
+			Severity: SeverityInfo,
+			Object:   name,
+			Message:  "Dry run is enabled, nothing is applied",
+			Hint:     "Set spec.dryRun to false to install the components.",
+		})
+	}
+	for _, condition := range orchestrator.Status.Conditions {
+		if condition.Status != metav1.ConditionFalse || condition.Reason == ReasonDisabled {
+			continue
+		}
+		severity := SeverityError
+		switch condition.Reason {
+		case ReasonWaitingForCRD, ReasonDependencyNotReady:
+			severity = SeverityWarning
+		case ReasonPaused:
+			severity = SeverityInfo
+		}
+		findings = append(findings, Finding{
+			Severity: severity,
+			Object:   name,
+			Message:  fmt.Sprintf("%s is False (%s): %s", condition.Type, condition.Reason, condition.Message),
+			Hint:     conditionHints[condition.Reason],
+		})
+	}
+	return findings
+}
+
+func diagnoseSubscription(object client.Object, _ *unstructured.Unstructured) []Finding {
+	subscription, ok := object.(*operatorsv1alpha1.Subscription)
+	if !ok {
+		return nil
+	}
+	name := objectName("Subscription", object)
+	findings := []Finding{}
+	for _, condition := range subscription.Status.Conditions {
+		if condition.Type == operatorsv1alpha1.SubscriptionResolutionFailed && condition.Status == corev1.ConditionTrue {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Object:   name,
+				Message:  "OLM failed to resolve the subscription: " + condition.Message,
+				Hint: fmt.Sprintf("Check that the catalog source %s/%s provides the package %s in the channel %s.",
+					subscription.Spec.CatalogSourceNamespace, subscription.Spec.CatalogSource, subscription.Spec.Package, subscription.Spec.Channel),
+			})
+		}
+	}
+	for _, health := range subscription.Status.CatalogHealth {
+		if !health.Healthy && health.CatalogSourceRef != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Object:   name,
+				Message:  fmt.Sprintf("The catalog source %s/%s is unhealthy", health.CatalogSourceRef.Namespace, health.CatalogSourceRef.Name),
+				Hint:     "Check the pod of the catalog source, or the mirrored catalog source when running air-gapped.",
+			})
+		}
+	}
+	return findings
+}
+
+func diagnoseInstallPlan(object client.Object, _ *unstructured.Unstructured) []Finding {
+	installPlan, ok := object.(*operatorsv1alpha1.InstallPlan)
+	if !ok {
+		return nil
+	}
+	name := objectName("InstallPlan", object)
+	if installPlan.Status.Phase == operatorsv1alpha1.InstallPlanPhaseFailed {
+		messages := []string{}
+		for _, condition := range installPlan.Status.Conditions {
+			if condition.Message != "" {
+				messages = append(messages, condition.Message)
+			}
+		}
+		return []Finding{{
+			Severity: SeverityError,
+			Object:   name,
+			Message:  "The install plan failed: " + strings.Join(messages, "; "),
+		}}
+	}
+	if installPlan.Spec.Approval == operatorsv1alpha1.ApprovalManual && !installPlan.Spec.Approved {
+		return []Finding{{
+			Severity: SeverityWarning,
+			Object:   name,
+			Message:  fmt.Sprintf("The install plan of %s is waiting for a manual approval", strings.Join(installPlan.Spec.ClusterServiceVersionNames, ", ")),
+			Hint:     "Approve the InstallPlan, or set the installPlanApproval of the subscription to Automatic.",
+		}}
+	}
+	return nil
+}
+
+func diagnoseCSV(object client.Object, _ *unstructured.Unstructured) []Finding {
+	csv, ok := object.(*operatorsv1alpha1.ClusterServiceVersion)
+	if !ok {
+		return nil
+	}
+	if csv.Status.Phase != operatorsv1alpha1.CSVPhaseFailed && csv.Status.Reason != operatorsv1alpha1.CSVReasonRequirementsNotMet {
+		return nil
+	}
+	return []Finding{{
+		Severity: SeverityError,
+		Object:   objectName("ClusterServiceVersion", object),
+		Message:  fmt.Sprintf("The CSV is %s (%s): %s", csv.Status.Phase, csv.Status.Reason, csv.Status.Message),
+		Hint:     "Check the requirements of the CSV and the logs of the OLM operator.",
+	}}
+}
+
+func diagnosePod(object client.Object, _ *unstructured.Unstructured) []Finding {
+	pod, ok := object.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	name := objectName("Pod", object)
+	findings := []Finding{}
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if status.State.Waiting == nil {
+			continue
+		}
+		hint, found := waitingReasons[status.State.Waiting.Reason]
+		if !found {
+			continue
+		}
+		message := fmt.Sprintf("Container %s is in %s", status.Name, status.State.Waiting.Reason)
+		if status.State.Waiting.Message != "" {
+			message += ": " + status.State.Waiting.Message
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Object:   name,
+			Message:  message,
+			Hint:     hint,
+		})
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Object:   name,
+				Message:  "The pod can't be scheduled: " + condition.Message,
+				Hint:     "Check the resources available on the nodes and the quotas of the namespace.",
+			})
+		}
+	}
+	return findings
+}
+
+// diagnoseJob reports the failed Jobs, such as the setup of the database
+// schema of a workflow.
+func diagnoseJob(object client.Object, _ *unstructured.Unstructured) []Finding {
+	job, ok := object.(*batchv1.Job)
+	if !ok {
+		return nil
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return []Finding{{
+				Severity: SeverityError,
+				Object:   objectName("Job", object),
+				Message:  fmt.Sprintf("The job failed (%s): %s", condition.Reason, condition.Message),
+				Hint:     "Check the logs of its pods. The database credentials must be allowed to create roles and schemas.",
+			}}
+		}
+	}
+	return nil
+}
+
+// diagnoseNotReady reports the resources of the components whose readiness
+// condition is False. The conditions are read from the unstructured content,
+// as the APIs of the operators report them with different types.
+func diagnoseNotReady(object client.Object, manifest *unstructured.Unstructured) []Finding {
+	kind := manifest.GetKind()
+	conditionType, found := readyConditions[kind]
+	if !found {
+		return nil
+	}
+	conditions, _, _ := unstructured.NestedSlice(manifest.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, _ := item.(map[string]interface{})
+		if condition["type"] != conditionType || condition["status"] != string(metav1.ConditionFalse) {
+			continue
+		}
+		return []Finding{{
+			Severity: SeverityError,
+			Object:   objectName(kind, object),
+			Message:  fmt.Sprintf("%s is False (%v): %v", conditionType, condition["reason"], condition["message"]),
+			Hint:     "Check the logs of the operator reconciling it.",
+		}}
+	}
+	return nil
+}
+
+func objectName(kind string, object client.Object) string {
+	if object.GetNamespace() == "" {
+		return kind + " " + object.GetName()
+	}
+	return fmt.Sprintf("%s %s/%s", kind, object.GetNamespace(), object.GetName())
+}
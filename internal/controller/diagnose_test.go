@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+
+	sonataapi "github.com/apache/incubator-kie-kogito-serverless-operator/api/v1alpha08"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	knative "knative.dev/operator/pkg/apis/operator/v1beta1"
+	backstagev1alpha1 "redhat-developer/red-hat-developer-hub-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Diagnose", func() {
+	ctx := context.Background()
+
+	It("should collect the resources and report the known failures", func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorsv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(orchestratorv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(knative.AddToScheme(scheme)).To(Succeed())
+		Expect(sonataapi.AddToScheme(scheme)).To(Succeed())
+		Expect(backstagev1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		orchestrator := &orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "orchestrator"}}
+		orchestrator.Spec.ServerlessOperator.Subscription = orchestratorv1alpha1.Subscription{
+			Namespace: KnativeSubscriptionNamespace,
+			Name:      KnativeSubscriptionName,
+		}
+		orchestrator.Status.Conditions = []metav1.Condition{
+			{Type: TypeKnativeReady, Status: metav1.ConditionFalse, Reason: string(kube.OperatorInstallFailed), Message: "install failed"},
+			{Type: TypeBackstageReady, Status: metav1.ConditionFalse, Reason: ReasonDisabled},
+		}
+		subscription := &operatorsv1alpha1.Subscription{
+			ObjectMeta: metav1.ObjectMeta{Namespace: KnativeSubscriptionNamespace, Name: KnativeSubscriptionName},
+			Status: operatorsv1alpha1.SubscriptionStatus{
+				InstallPlanRef: &corev1.ObjectReference{Namespace: KnativeSubscriptionNamespace, Name: "install-abcde"},
+			},
+		}
+		installPlan := &operatorsv1alpha1.InstallPlan{
+			ObjectMeta: metav1.ObjectMeta{Namespace: KnativeSubscriptionNamespace, Name: "install-abcde"},
+			Spec: operatorsv1alpha1.InstallPlanSpec{
+				Approval:                   operatorsv1alpha1.ApprovalManual,
+				ClusterServiceVersionNames: []string{"serverless-operator.v1.33.0"},
+			},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: KnativeSubscriptionNamespace, Name: "knative-operator"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "operator", Env: []corev1.EnvVar{
+				{Name: "POSTGRES_PASSWORD", Value: "s3cr3t"},
+				{Name: "BACKEND_SECRET", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "backstage-backend-auth-secret"},
+					Key:                  "BACKEND_SECRET",
+				}}},
+			}}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "operator",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+			}}},
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "rhdh", Name: "backstage-backend-auth-secret", Labels: kube.AddLabel()},
+			Data:       map[string][]byte{"BACKEND_SECRET": []byte("s3cr3t")},
+		}
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(orchestrator, subscription, installPlan, pod, secret).
+			Build()
+
+		diagnostics := Diagnose(ctx, k8sClient, k8sfake.NewSimpleClientset(), scheme, DiagnoseOptions{LogLines: 100})
+
+		Expect(diagnostics.Errors).To(BeEmpty())
+		Expect(diagnostics.Orchestrators).To(Equal([]string{"default/orchestrator"}))
+		kinds := []string{}
+		for _, object := range diagnostics.Objects {
+			kinds = append(kinds, object.GetKind())
+			if object.GetKind() == "Secret" {
+				value, _, _ := unstructured.NestedString(object.Object, "data", "BACKEND_SECRET")
+				Expect(value).To(Equal(base64.StdEncoding.EncodeToString([]byte(RedactedValue))))
+			}
+			if object.GetKind() == "Pod" {
+				containers, _, _ := unstructured.NestedSlice(object.Object, "spec", "containers")
+				env := containers[0].(map[string]interface{})["env"].([]interface{})
+				Expect(env[0]).To(HaveKeyWithValue("value", RedactedValue))
+				Expect(env[1]).NotTo(HaveKey("value"))
+				Expect(env[1]).To(HaveKey("valueFrom"))
+			}
+		}
+		Expect(kinds).To(ContainElements("Orchestrator", "Subscription", "InstallPlan", "Pod", "Secret"))
+		Expect(diagnostics.Logs).To(HaveKey("openshift-serverless/knative-operator/operator"))
+
+		messages := []string{}
+		for _, finding := range diagnostics.Findings {
+			messages = append(messages, finding.Object+": "+finding.Message)
+		}
+		Expect(messages).To(ConsistOf(
+			"Orchestrator default/orchestrator: KnativeReady is False (OperatorInstallFailed): install failed",
+			"InstallPlan openshift-serverless/install-abcde: The install plan of serverless-operator.v1.33.0 is waiting for a manual approval",
+			"Pod openshift-serverless/knative-operator: Container operator is in ImagePullBackOff",
+		))
+		Expect(diagnostics.Summary()).To(ContainSubstring("[Error] Orchestrator default/orchestrator"))
+	})
+})
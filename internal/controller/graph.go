@@ -0,0 +1,140 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ReasonDependencyNotReady is the reason of the Ready condition of a
+// component skipped because one of its dependencies is not ready.
+const ReasonDependencyNotReady = "DependencyNotReady"
+
+// componentNode is a component of the dependency graph, reconciled once all
+// the components it depends on are reconciled successfully.
+type componentNode struct {
+	name          string
+	conditionType string
+	dependsOn     []string
+	reconcile     func(ctx context.Context) error
+}
+
+// dependencyNotReadyError is returned for a component that was not
+// reconciled because some of its dependencies failed or are still waiting.
+type dependencyNotReadyError struct {
+	component    string
+	dependencies []string
+}
+
+func (e *dependencyNotReadyError) Error() string {
+	return fmt.Sprintf("%s is waiting for %s to be ready", e.component, strings.Join(e.dependencies, ", "))
+}
+
+// reconcileGraph reconciles the components concurrently, each one as soon as
+// its dependencies are reconciled, and returns the error of each component.
+// A component whose dependency didn't succeed is not reconciled and gets a
+// dependencyNotReadyError.
+func reconcileGraph(ctx context.Context, nodes []componentNode) (map[string]error, error) {
+	if err := validateGraph(nodes); err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	results := make(map[string]error, len(nodes))
+	done := make(map[string]chan struct{}, len(nodes))
+	for _, node := range nodes {
+		done[node.name] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node componentNode) {
+			defer wg.Done()
+			defer close(done[node.name])
+
+			notReady := []string{}
+			for _, dependency := range node.dependsOn {
+				<-done[dependency]
+				mu.Lock()
+				if results[dependency] != nil {
+					notReady = append(notReady, dependency)
+				}
+				mu.Unlock()
+			}
+
+			var err error
+			if len(notReady) > 0 {
+				err = &dependencyNotReadyError{component: node.name, dependencies: notReady}
+			} else {
+				err = node.reconcile(ctx)
+			}
+			mu.Lock()
+			results[node.name] = err
+			mu.Unlock()
+		}(node)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// validateGraph checks that the dependencies are known components and that
+// they don't form a cycle, which would block the reconciliation.
+func validateGraph(nodes []componentNode) error {
+	dependencies := make(map[string][]string, len(nodes))
+	for _, node := range nodes {
+		if _, found := dependencies[node.name]; found {
+			return fmt.Errorf("component %s is defined twice", node.name)
+		}
+		dependencies[node.name] = node.dependsOn
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("components have a dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dependency := range dependencies[name] {
+			if _, found := dependencies[dependency]; !found {
+				return fmt.Errorf("component %s depends on unknown component %s", name, dependency)
+			}
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	for _, node := range nodes {
+		if err := visit(node.name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
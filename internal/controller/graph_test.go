@@ -0,0 +1,113 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Component dependency graph", func() {
+	ctx := context.Background()
+
+	succeed := func(context.Context) error { return nil }
+
+	It("should reconcile independent components concurrently", func() {
+		var started sync.WaitGroup
+		started.Add(2)
+		// each component only returns once both have started
+		barrier := func(context.Context) error {
+			started.Done()
+			done := make(chan struct{})
+			go func() { started.Wait(); close(done) }()
+			select {
+			case <-done:
+				return nil
+			case <-time.After(5 * time.Second):
+				return errors.New("components were reconciled sequentially")
+			}
+		}
+
+		results, err := reconcileGraph(ctx, []componentNode{
+			{name: "a", reconcile: barrier},
+			{name: "b", reconcile: barrier},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveKeyWithValue("a", BeNil()))
+		Expect(results).To(HaveKeyWithValue("b", BeNil()))
+	})
+
+	It("should reconcile a component after its dependencies", func() {
+		var mu sync.Mutex
+		order := []string{}
+		record := func(name string) func(context.Context) error {
+			return func(context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return nil
+			}
+		}
+
+		_, err := reconcileGraph(ctx, []componentNode{
+			{name: "backstage", dependsOn: []string{"sonataflow"}, reconcile: record("backstage")},
+			{name: "sonataflow", dependsOn: []string{"knative"}, reconcile: record("sonataflow")},
+			{name: "knative", reconcile: record("knative")},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(order).To(Equal([]string{"knative", "sonataflow", "backstage"}))
+	})
+
+	It("should isolate a failure to the component and its dependents", func() {
+		failure := errors.New("boom")
+		reconciled := false
+		results, err := reconcileGraph(ctx, []componentNode{
+			{name: "knative", reconcile: func(context.Context) error { return failure }},
+			{name: "sonataflow", dependsOn: []string{"knative"}, reconcile: func(context.Context) error {
+				reconciled = true
+				return nil
+			}},
+			{name: "postgres", reconcile: succeed},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciled).To(BeFalse())
+		Expect(results["knative"]).To(MatchError(failure))
+		Expect(results["postgres"]).To(Succeed())
+
+		var notReady *dependencyNotReadyError
+		Expect(errors.As(results["sonataflow"], &notReady)).To(BeTrue())
+		Expect(notReady.dependencies).To(Equal([]string{"knative"}))
+	})
+
+	It("should reject cycles and unknown dependencies", func() {
+		_, err := reconcileGraph(ctx, []componentNode{
+			{name: "a", dependsOn: []string{"b"}, reconcile: succeed},
+			{name: "b", dependsOn: []string{"a"}, reconcile: succeed},
+		})
+		Expect(err).To(MatchError(ContainSubstring("dependency cycle")))
+
+		_, err = reconcileGraph(ctx, []componentNode{
+			{name: "a", dependsOn: []string{"missing"}, reconcile: succeed},
+		})
+		Expect(err).To(MatchError(ContainSubstring("unknown component missing")))
+	})
+})
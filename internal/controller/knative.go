@@ -19,7 +19,9 @@ package controller
 import (
 	"context"
 	olmclientset "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
 	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -38,6 +40,9 @@ const (
 	KnativeServingCRDName         = "knativeservings.operator.knative.dev"
 	KnativeSubscriptionName       = "serverless-operator"
 	KnativeSubscriptionNamespace  = "openshift-serverless"
+	KnativeBrokerAPIVersion       = "eventing.knative.dev/v1"
+	KnativeBrokerKind             = "Broker"
+	KnativeTriggerKind            = "Trigger"
 )
 
 func handleKnativeEventingCR(ctx context.Context, client client.Client) error {
@@ -66,8 +71,11 @@ func handleKnativeEventingCR(ctx context.Context, client client.Client) error {
 			}
 			if err = client.Create(ctx, knEventing); err != nil {
 				logger.Error(err, "Error occurred when creating CR resource", "CR-Name", knEventing.Name)
+				kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create %s %s/%s: %v", KnativeEventingKind, knEventing.Namespace, knEventing.Name, err)
+				return err
 			}
 			logger.Info("Successfully created Knative Eventing resource", "CR-Name", knEventing.Name)
+			kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created %s %s/%s", KnativeEventingKind, knEventing.Namespace, knEventing.Name)
 		}
 	}
 	return err
@@ -98,13 +106,16 @@ func handleKnativeServingCR(ctx context.Context, client client.Client) error {
 		}
 		if err = client.Create(ctx, knServing); err != nil {
 			logger.Error(err, "Error occurred when creating CR resource", "CR-Name", knServing.Name)
+			kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create %s %s/%s: %v", KnativeServingKind, knServing.Namespace, knServing.Name, err)
+			return err
 		}
 		logger.Info("Successfully created Knative Serving resource", "CR-Name", knServing.Name)
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created %s %s/%s", KnativeServingKind, knServing.Namespace, knServing.Name)
 	}
 	return err
 }
 
-func handleKnativeCleanUp(ctx context.Context, client client.Client, olmClientSet olmclientset.Clientset) error {
+func handleKnativeCleanUp(ctx context.Context, client client.Client, olmClientSet olmclientset.Interface) error {
 	logger := log.FromContext(ctx)
 	// remove all namespace
 	if err := kube.CleanUpNamespace(ctx, KnativeEventingNamespacedName, client); err != nil {
@@ -123,3 +134,57 @@ func handleKnativeCleanUp(ctx context.Context, client client.Client, olmClientSe
 	// remove all CRDs, optional (ensure all CRs and namespace have been removed first)
 	return nil
 }
+
+// knativeComponent installs the OpenShift Serverless operator and the
+// Knative Eventing and Serving instances.
+type knativeComponent struct{}
+
+func (c *knativeComponent) Name() string { return metrics.ComponentKnative }
+
+func (c *knativeComponent) ConditionType() string { return TypeKnativeReady }
+
+func (c *knativeComponent) DependsOn() []string { return nil }
+
+func (c *knativeComponent) Enabled(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.ServerlessOperator.Enabled
+}
+
+func (c *knativeComponent) Paused(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.ServerlessOperator.Paused
+}
+
+func (c *knativeComponent) Prerequisites(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	return ensureNamespace(ctx, env, spec.ServerlessOperator.Subscription.Namespace)
+}
+
+func (c *knativeComponent) Install(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	return installOperator(ctx, env, c.Name(), kube.ServerlessOperatorGroupName, spec.ServerlessOperator.Subscription)
+}
+
+func (c *knativeComponent) Reconcile(
+	ctx context.Context,
+	env ComponentEnv,
+	_ orchestratorv1alpha1.OrchestratorSpec) (ComponentResult, error) {
+	logger := log.FromContext(ctx)
+	// wait until the operator serves the eventing and serving APIs
+	if err := checkAPIs(ctx, env, c.Name(), knativeAPIs); err != nil {
+		return ComponentResult{}, err
+	}
+	if err := handleKnativeEventingCR(ctx, env.Client); err != nil {
+		logger.Error(err, "Error occurred when creating Knative EventingCR", "CR-Name", KnativeEventingNamespacedName)
+		return ComponentResult{}, err
+	}
+	if err := handleKnativeServingCR(ctx, env.Client); err != nil {
+		logger.Error(err, "Error occurred when creating Knative ServingCR", "CR-Name", KnativeServingNamespacedName)
+		return ComponentResult{}, err
+	}
+	return ComponentResult{}, nil
+}
+
+func (c *knativeComponent) Status(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) (ComponentStatus, error) {
+	return subscriptionStatus(ctx, env, spec.ServerlessOperator.Subscription)
+}
+
+func (c *knativeComponent) Cleanup(ctx context.Context, env ComponentEnv, _ orchestratorv1alpha1.OrchestratorSpec) error {
+	return handleKnativeCleanUp(ctx, env.Client, env.OLMClient)
+}
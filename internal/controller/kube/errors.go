@@ -0,0 +1,102 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ErrorKind classifies reconcile errors. The kind is used as the Reason of
+// the status conditions and decides whether the reconciliation is retried.
+type ErrorKind string
+
+const (
+	// MissingPrerequisite is returned when a resource the operator depends on,
+	// such as a CRD, a namespace or a referenced secret, does not exist yet.
+	MissingPrerequisite ErrorKind = "MissingPrerequisite"
+	// InvalidSpec is returned when the Orchestrator spec cannot be applied.
+	// It is terminal: retrying won't help until the spec changes.
+	InvalidSpec ErrorKind = "InvalidSpec"
+	// TransientAPI is returned when a call to the API server or an external
+	// service failed and may succeed on retry.
+	TransientAPI ErrorKind = "TransientAPI"
+	// OperatorInstallFailed is returned when OLM failed to install an operator.
+	OperatorInstallFailed ErrorKind = "OperatorInstallFailed"
+)
+
+// Error is a reconcile error of a given kind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Terminal returns whether retrying the reconciliation can't fix the error.
+func (k ErrorKind) Terminal() bool {
+	return k == InvalidSpec
+}
+
+// NewError returns an error of the given kind with a formatted message.
+func NewError(kind ErrorKind, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+// WrapError classifies err with the given kind. Errors that are already
+// classified keep their kind, and nil is returned for a nil error.
+func WrapError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var kubeErr *Error
+	if errors.As(err, &kubeErr) {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// ErrorKindOf returns the kind of err. Errors that were not classified are
+// derived from the API status: rejected objects are InvalidSpec, missing
+// objects are MissingPrerequisite and any other error is TransientAPI.
+func ErrorKindOf(err error) ErrorKind {
+	var kubeErr *Error
+	if errors.As(err, &kubeErr) {
+		return kubeErr.Kind
+	}
+	switch {
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return InvalidSpec
+	case apierrors.IsNotFound(err):
+		return MissingPrerequisite
+	default:
+		return TransientAPI
+	}
+}
+
+// IsTerminal returns whether retrying the reconciliation can't fix err.
+func IsTerminal(err error) bool {
+	return ErrorKindOf(err).Terminal()
+}
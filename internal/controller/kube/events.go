@@ -0,0 +1,92 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events recorded on the Orchestrator resource.
+const (
+	ReasonNamespaceCreated          = "NamespaceCreated"
+	ReasonNamespaceCreationFailed   = "NamespaceCreationFailed"
+	ReasonNamespaceDeleted          = "NamespaceDeleted"
+	ReasonSubscriptionCreated       = "SubscriptionCreated"
+	ReasonSubscriptionFailed        = "SubscriptionCreationFailed"
+	ReasonSubscriptionDeleted       = "SubscriptionDeleted"
+	ReasonCSVSucceeded              = "CSVSucceeded"
+	ReasonCSVFailed                 = "CSVFailed"
+	ReasonCSVDeleted                = "CSVDeleted"
+	ReasonResourceCreated           = "ResourceCreated"
+	ReasonResourceCreationFailed    = "ResourceCreationFailed"
+	ReasonResourceUpdated           = "ResourceUpdated"
+	ReasonResourceUpdateFailed      = "ResourceUpdateFailed"
+	ReasonResourceDeleted           = "ResourceDeleted"
+	ReasonCleanupFailed             = "CleanupFailed"
+	ReasonActionSkipped             = "ActionSkipped"
+	ReasonOperatorGroupCreated      = "OperatorGroupCreated"
+	ReasonOperatorGroupCreateFailed = "OperatorGroupCreationFailed"
+	ReasonActionPlanned             = "ActionPlanned"
+)
+
+// Events records lifecycle events on the Orchestrator resource. The zero
+// value drops the events.
+type Events struct {
+	recorder record.EventRecorder
+	object   runtime.Object
+}
+
+type eventsKey struct{}
+
+// NewEvents returns the events recorded on object.
+func NewEvents(recorder record.EventRecorder, object runtime.Object) Events {
+	return Events{recorder: recorder, object: object}
+}
+
+// IntoContext returns a context carrying the events recorder, the same way
+// the logger is carried to the helpers.
+func (e Events) IntoContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, eventsKey{}, e)
+}
+
+// EventsFromContext returns the events recorder of the context, which drops
+// the events when none was set.
+func EventsFromContext(ctx context.Context) Events {
+	events, _ := ctx.Value(eventsKey{}).(Events)
+	return events
+}
+
+// Normal records an event about an action performed as expected.
+func (e Events) Normal(reason, messageFmt string, args ...interface{}) {
+	e.record(corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+// Warning records an event about a failed or skipped action.
+func (e Events) Warning(reason, messageFmt string, args ...interface{}) {
+	e.record(corev1.EventTypeWarning, reason, messageFmt, args...)
+}
+
+func (e Events) record(eventType, reason, messageFmt string, args ...interface{}) {
+	if e.recorder == nil || e.object == nil {
+		return
+	}
+	e.recorder.Eventf(e.object, eventType, reason, messageFmt, args...)
+}
@@ -59,14 +59,16 @@ func CreateNamespace(ctx context.Context, client client.Client, namespace string
 	err := client.Create(ctx, newNamespace)
 	if err != nil {
 		nsLogger.Error(err, "Error occurred when creating namespace", "Namespace", namespace)
+		EventsFromContext(ctx).Warning(ReasonNamespaceCreationFailed, "Failed to create namespace %s: %v", namespace, err)
 		return err
 	}
+	EventsFromContext(ctx).Normal(ReasonNamespaceCreated, "Created namespace %s", namespace)
 	return nil
 }
 
 func InstallOperatorViaSubscription(
 	ctx context.Context, client client.Client,
-	olmClientSet olmclientset.Clientset,
+	olmClientSet olmclientset.Interface,
 	operatorGroupName string,
 	subscription orchestratorv1alpha1.Subscription) error {
 
@@ -82,6 +84,9 @@ func InstallOperatorViaSubscription(
 		logger.Error(err, "Failed to get operator group resource", "OperatorGroup", operatorGroupName)
 	}
 	// install subscription
+	if RecordPlannedAction(ctx, PlanCreate, "Subscription", namespace, subscriptionName) {
+		return nil
+	}
 	subscriptionObject := createSubscriptionObject(subscriptionName, namespace, subscription)
 	installedSubscription, err := olmClientSet.OperatorsV1alpha1().
 		Subscriptions(namespace).
@@ -89,7 +94,14 @@ func InstallOperatorViaSubscription(
 
 	if err != nil {
 		logger.Error(err, "Error occurred while creating Subscription", "SubscriptionName", subscriptionName)
+		EventsFromContext(ctx).Warning(ReasonSubscriptionFailed, "Failed to create subscription %s/%s: %v", namespace, subscriptionName, err)
+		if apierrors.IsInvalid(err) {
+			return err
+		}
+		return WrapError(OperatorInstallFailed, err)
 	}
+	EventsFromContext(ctx).Normal(ReasonSubscriptionCreated, "Created subscription %s/%s from catalog source %s/%s",
+		namespace, subscriptionName, subscriptionObject.Spec.CatalogSourceNamespace, subscriptionObject.Spec.CatalogSource)
 	// Check the Subscription's status after installation
 	installedCSV := installedSubscription.Status.InstalledCSV
 	if installedCSV == "" {
@@ -128,8 +140,10 @@ func getOperatorGroup(ctx context.Context, client client.Client,
 	err = client.Create(ctx, sfog)
 	if err != nil {
 		logger.Error(err, "Error occurred when creating OperatorGroup resource", "Namespace", namespace)
+		EventsFromContext(ctx).Warning(ReasonOperatorGroupCreateFailed, "Failed to create operator group %s/%s: %v", namespace, operatorGroupName, err)
 		return err
 	}
+	EventsFromContext(ctx).Normal(ReasonOperatorGroupCreated, "Created operator group %s/%s", namespace, operatorGroupName)
 	return nil
 }
 
@@ -139,6 +153,10 @@ func createSubscriptionObject(
 	logger := log.Log.WithName("subscriptionObject")
 	logger.Info("Creating subscription object")
 
+	catalogSourceNamespace := subscription.SourceNamespace
+	if catalogSourceNamespace == "" {
+		catalogSourceNamespace = CatalogSourceNamespace
+	}
 	subscriptionObject := &v1alpha1.Subscription{
 		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: subscriptionName},
 		Spec: &v1alpha1.SubscriptionSpec{
@@ -146,15 +164,46 @@ func createSubscriptionObject(
 			InstallPlanApproval:    v1alpha1.Approval(subscription.InstallPlanApproval),
 			CatalogSource:          subscription.SourceName,
 			StartingCSV:            subscription.StartingCSV,
-			CatalogSourceNamespace: CatalogSourceNamespace,
+			CatalogSourceNamespace: catalogSourceNamespace,
 			Package:                subscription.Name,
 		},
 	}
 	return subscriptionObject
 }
 
+// UpdateSubscriptionSource moves an existing subscription to the catalog
+// source of the spec, such as the mirrored catalog source of the air-gapped
+// profile. OLM resolves the next updates of the operator from that source.
+func UpdateSubscriptionSource(
+	ctx context.Context, olmClientSet olmclientset.Interface,
+	installed *v1alpha1.Subscription,
+	subscription orchestratorv1alpha1.Subscription) error {
+	logger := log.FromContext(ctx)
+
+	desired := createSubscriptionObject(subscription.Name, installed.Namespace, subscription)
+	if subscription.SourceName == "" || installed.Spec == nil ||
+		(installed.Spec.CatalogSource == desired.Spec.CatalogSource &&
+			installed.Spec.CatalogSourceNamespace == desired.Spec.CatalogSourceNamespace) {
+		return nil
+	}
+	if RecordPlannedAction(ctx, PlanUpdate, "Subscription", installed.Namespace, installed.Name) {
+		return nil
+	}
+	updated := installed.DeepCopy()
+	updated.Spec.CatalogSource = desired.Spec.CatalogSource
+	updated.Spec.CatalogSourceNamespace = desired.Spec.CatalogSourceNamespace
+	if _, err := olmClientSet.OperatorsV1alpha1().Subscriptions(installed.Namespace).Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		logger.Error(err, "Error occurred while updating Subscription", "SubscriptionName", installed.Name)
+		EventsFromContext(ctx).Warning(ReasonResourceUpdateFailed, "Failed to update subscription %s/%s: %v", installed.Namespace, installed.Name, err)
+		return err
+	}
+	EventsFromContext(ctx).Normal(ReasonResourceUpdated, "Moved subscription %s/%s to catalog source %s/%s",
+		installed.Namespace, installed.Name, desired.Spec.CatalogSourceNamespace, desired.Spec.CatalogSource)
+	return nil
+}
+
 func CheckSubscriptionExists(
-	ctx context.Context, olmClientSet olmclientset.Clientset,
+	ctx context.Context, olmClientSet olmclientset.Interface,
 	namespace, subscriptionName string) (bool, *v1alpha1.Subscription, error) {
 	logger := log.FromContext(ctx)
 
@@ -171,6 +220,30 @@ func CheckSubscriptionExists(
 	return true, subscription, nil
 }
 
+// GetInstalledCSV returns the ClusterServiceVersion installed by the
+// subscription, or nil when OLM has not installed one yet.
+func GetInstalledCSV(
+	ctx context.Context, olmClientSet olmclientset.Interface,
+	subscription *v1alpha1.Subscription) (*v1alpha1.ClusterServiceVersion, error) {
+	logger := log.FromContext(ctx)
+
+	csvName := subscription.Status.InstalledCSV
+	if csvName == "" {
+		logger.Info("Subscription has no installed CSV", "SubscriptionName", subscription.Name)
+		return nil, nil
+	}
+	csv, err := olmClientSet.OperatorsV1alpha1().ClusterServiceVersions(subscription.Namespace).Get(ctx, csvName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("CSV resource not found", "CSV", csvName)
+			return nil, nil
+		}
+		logger.Error(err, "Error occurred when getting CSV", "CSV", csvName)
+		return nil, err
+	}
+	return csv, nil
+}
+
 func CheckCRDExists(ctx context.Context, client client.Client, name string, namespace string) error {
 	crd := &apiextensionsv1.CustomResourceDefinition{}
 	err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, crd)
@@ -196,13 +269,15 @@ func CleanUpNamespace(ctx context.Context, namespaceName string, client client.C
 	}
 	// delete namespace
 	if err := client.Delete(ctx, namespace); err != nil && !apierrors.IsNotFound(err) {
+		EventsFromContext(ctx).Warning(ReasonCleanupFailed, "Failed to delete namespace %s: %v", namespaceName, err)
 		return err
 	}
 	logger.Info("Successfully deleted Namespace", "Namespace", namespaceName)
+	EventsFromContext(ctx).Normal(ReasonNamespaceDeleted, "Deleted namespace %s", namespaceName)
 	return nil
 }
 
-func CleanUpSubscriptionAndCSV(ctx context.Context, olmClientSet olmclientset.Clientset, subscriptionName, namespace string) error {
+func CleanUpSubscriptionAndCSV(ctx context.Context, olmClientSet olmclientset.Interface, subscriptionName, namespace string) error {
 	logger := log.FromContext(ctx)
 	// check if subscription exists using olm client
 	subscriptionExists, subscription, err := CheckSubscriptionExists(ctx, olmClientSet, namespace, subscriptionName)
@@ -213,15 +288,23 @@ func CleanUpSubscriptionAndCSV(ctx context.Context, olmClientSet olmclientset.Cl
 	if subscriptionExists {
 		// get name of csv before deletion
 		csvName := subscription.Status.InstalledCSV
+		if RecordPlannedAction(ctx, PlanDelete, "Subscription", namespace, subscriptionName) {
+			if csvName != "" {
+				RecordPlannedAction(ctx, PlanDelete, "ClusterServiceVersion", namespace, csvName)
+			}
+			return nil
+		}
 
 		// deleting subscription resource
 		err = olmClientSet.OperatorsV1alpha1().Subscriptions(namespace).Delete(ctx, subscriptionName, metav1.DeleteOptions{})
 		if err != nil {
 			logger.Error(err, "Error occurred while deleting Subscription", "SubscriptionName", subscriptionName, "Namespace", namespace)
 			//return ctrl.Result{RequeueAfter: 5 * time.Minute}, err
+			EventsFromContext(ctx).Warning(ReasonCleanupFailed, "Failed to delete subscription %s/%s: %v", namespace, subscriptionName, err)
 			return err
 		}
 		logger.Info("Successfully deleted Subscription: %s", subscriptionName)
+		EventsFromContext(ctx).Normal(ReasonSubscriptionDeleted, "Deleted subscription %s/%s", namespace, subscriptionName)
 
 		// cleanup csv
 		csv, err := olmClientSet.OperatorsV1alpha1().ClusterServiceVersions(namespace).Get(ctx, csvName, metav1.GetOptions{})
@@ -234,9 +317,11 @@ func CleanUpSubscriptionAndCSV(ctx context.Context, olmClientSet olmclientset.Cl
 			return err
 		}
 		if err := olmClientSet.OperatorsV1alpha1().ClusterServiceVersions(csv.Namespace).Delete(ctx, csv.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			EventsFromContext(ctx).Warning(ReasonCleanupFailed, "Failed to delete CSV %s/%s: %v", namespace, csvName, err)
 			return err
 		}
 		logger.Info("Successfully deleted CSV", "CSV", csvName)
+		EventsFromContext(ctx).Normal(ReasonCSVDeleted, "Deleted CSV %s/%s", namespace, csvName)
 		return nil
 	}
 	return err
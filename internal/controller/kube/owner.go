@@ -0,0 +1,77 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OwnerAnnotationKey records the namespace/name of the Orchestrator that last
+// wrote an object labeled as created by the orchestrator.
+const OwnerAnnotationKey = "orchestrator.parodos.dev/owner"
+
+// ownerClient records its owner in the objects labeled as created by the
+// orchestrator it creates or updates.
+type ownerClient struct {
+	client.Client
+	owner types.NamespacedName
+}
+
+// NewOwnerClient returns a client recording owner in the objects it writes.
+func NewOwnerClient(c client.Client, owner types.NamespacedName) client.Client {
+	return &ownerClient{Client: c, owner: owner}
+}
+
+func (c *ownerClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.annotate(obj)
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *ownerClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.annotate(obj)
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *ownerClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.annotate(obj)
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *ownerClient) annotate(obj client.Object) {
+	if obj.GetLabels()[CreatedByLabelKey] != CreatedByLabelValue {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[OwnerAnnotationKey] = c.owner.String()
+	obj.SetAnnotations(annotations)
+}
+
+// OwnerOf returns the Orchestrator recorded as the owner of object, if any.
+func OwnerOf(object client.Object) (types.NamespacedName, bool) {
+	namespace, name, found := strings.Cut(object.GetAnnotations()[OwnerAnnotationKey], "/")
+	if !found || name == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}, true
+}
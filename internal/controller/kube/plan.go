@@ -0,0 +1,137 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"sync"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Actions of a plan.
+const (
+	PlanCreate = "Create"
+	PlanUpdate = "Update"
+	PlanDelete = "Delete"
+)
+
+// Plan collects the writes the components would perform when running in dry
+// run, instead of applying them.
+type Plan struct {
+	mu      sync.Mutex
+	actions map[string][]orchestratorv1alpha1.PlannedAction
+}
+
+type planKey struct{}
+
+type planRecorder struct {
+	plan      *Plan
+	component string
+}
+
+// NewPlan returns an empty plan.
+func NewPlan() *Plan {
+	return &Plan{actions: map[string][]orchestratorv1alpha1.PlannedAction{}}
+}
+
+// IntoContext returns a context recording the writes of the component in the
+// plan, the same way the events are carried to the helpers.
+func (p *Plan) IntoContext(ctx context.Context, component string) context.Context {
+	return context.WithValue(ctx, planKey{}, planRecorder{plan: p, component: component})
+}
+
+// Actions returns the actions planned for the component, in the order they
+// were recorded.
+func (p *Plan) Actions(component string) []orchestratorv1alpha1.PlannedAction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]orchestratorv1alpha1.PlannedAction{}, p.actions[component]...)
+}
+
+// RecordPlannedAction records the action in the plan of the context and
+// returns true when running in dry run, in which case the caller must not
+// apply it.
+func RecordPlannedAction(ctx context.Context, action, kind, namespace, name string) bool {
+	recorder, ok := ctx.Value(planKey{}).(planRecorder)
+	if !ok {
+		return false
+	}
+	log.FromContext(ctx).Info("Planned action", "Action", action, "Kind", kind, "Namespace", namespace, "Name", name)
+	p := recorder.plan
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.actions[recorder.component] = append(p.actions[recorder.component], orchestratorv1alpha1.PlannedAction{
+		Component: recorder.component,
+		Action:    action,
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+	})
+	return true
+}
+
+// planningClient records the writes in the plan of the context, if any,
+// instead of sending them. The reads go to the cluster.
+type planningClient struct {
+	client.Client
+}
+
+// NewPlanningClient returns a client recording its writes in the plan of the
+// context when running in dry run.
+func NewPlanningClient(c client.Client) client.Client {
+	return &planningClient{Client: c}
+}
+
+func (c *planningClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if c.record(ctx, PlanCreate, obj) {
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *planningClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if c.record(ctx, PlanUpdate, obj) {
+		return nil
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *planningClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if c.record(ctx, PlanUpdate, obj) {
+		return nil
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *planningClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if c.record(ctx, PlanDelete, obj) {
+		return nil
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *planningClient) record(ctx context.Context, action string, obj client.Object) bool {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+		kind = gvk.Kind
+	}
+	return RecordPlannedAction(ctx, action, kind, obj.GetNamespace(), obj.GetName())
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Components label values.
+const (
+	ComponentSonataFlow = "sonataflow"
+	ComponentKnative    = "knative"
+	ComponentBackstage  = "backstage"
+	ComponentPostgreSQL = "postgresql"
+	ComponentWorkflows  = "workflows"
+	ComponentBrokers    = "brokers"
+)
+
+// Install phases reported for each component.
+const (
+	PhaseDisabled   = "Disabled"
+	PhaseInstalling = "Installing"
+	PhaseInstalled  = "Installed"
+	PhaseFailed     = "Failed"
+)
+
+var phases = []string{PhaseDisabled, PhaseInstalling, PhaseInstalled, PhaseFailed}
+
+// Labels of the Orchestrator resource a series belongs to. They are prefixed
+// so they don't clash with the namespace label of the scraped target.
+const (
+	LabelNamespace = "orchestrator_namespace"
+	LabelName      = "orchestrator_name"
+)
+
+var (
+	// ComponentPhase is 1 for the current install phase of a component and 0
+	// for the other phases.
+	ComponentPhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "orchestrator_component_phase",
+		Help: "Install phase of the orchestrator components, 1 for the current phase",
+	}, []string{LabelNamespace, LabelName, "component", "phase"})
+
+	// CSVSucceededSeconds observes the time from the creation of a
+	// subscription until its CSV reaches the Succeeded phase.
+	CSVSucceededSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "orchestrator_csv_succeeded_duration_seconds",
+		Help:    "Time from the creation of the operator subscription until the CSV succeeded",
+		Buckets: prometheus.ExponentialBuckets(10, 2, 10),
+	}, []string{LabelNamespace, LabelName, "component", "csv"})
+
+	// ReconcileErrors counts the failed reconciliations of each component.
+	ReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "orchestrator_reconcile_errors_total",
+		Help: "Number of reconcile errors by component and reason",
+	}, []string{LabelNamespace, LabelName, "component", "reason"})
+
+	// DriftCorrections counts the resources updated back to their desired
+	// state while the spec was unchanged.
+	DriftCorrections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "orchestrator_drift_corrections_total",
+		Help: "Number of managed resources updated to revert drift from the desired state",
+	}, []string{LabelNamespace, LabelName, "component", "kind"})
+
+	// PluginInfo is 1 for each Backstage plugin version deployed.
+	PluginInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "orchestrator_plugin_info",
+		Help: "Backstage plugin versions deployed by the orchestrator",
+	}, []string{LabelNamespace, LabelName, "plugin", "package", "version"})
+)
+
+// observedCSVs holds the CSVs whose time to succeed was already observed, so
+// each install is only observed once per process.
+var observedCSVs sync.Map
+
+func init() {
+	metrics.Registry.MustRegister(
+		ComponentPhase,
+		CSVSucceededSeconds,
+		ReconcileErrors,
+		DriftCorrections,
+		PluginInfo,
+	)
+}
+
+// Orchestrator identifies the Orchestrator resource the series recorded
+// during its reconciliation belong to.
+type Orchestrator struct {
+	Namespace string
+	Name      string
+	// SpecChanged is set while reconciling a generation of the spec that was
+	// not reconciled yet, the resources updated then follow the spec rather
+	// than revert a drift.
+	SpecChanged bool
+}
+
+type orchestratorKey struct{}
+
+// IntoContext returns a context labelling the series with the orchestrator,
+// the same way the events are carried to the helpers.
+func (o Orchestrator) IntoContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, orchestratorKey{}, o)
+}
+
+// OrchestratorFromContext returns the orchestrator of the context, the zero
+// value when none was set.
+func OrchestratorFromContext(ctx context.Context) Orchestrator {
+	orchestrator, _ := ctx.Value(orchestratorKey{}).(Orchestrator)
+	return orchestrator
+}
+
+func (o Orchestrator) labels() prometheus.Labels {
+	return prometheus.Labels{LabelNamespace: o.Namespace, LabelName: o.Name}
+}
+
+// SetComponentPhase sets the current install phase of the component.
+func SetComponentPhase(ctx context.Context, component, phase string) {
+	orchestrator := OrchestratorFromContext(ctx)
+	for _, p := range phases {
+		value := 0.0
+		if p == phase {
+			value = 1
+		}
+		ComponentPhase.WithLabelValues(orchestrator.Namespace, orchestrator.Name, component, p).Set(value)
+	}
+}
+
+// ObserveCSVSucceeded records the time the CSV took to succeed since the
+// subscription was created, once per CSV.
+func ObserveCSVSucceeded(ctx context.Context, component, csv string, subscriptionCreated, csvSucceeded time.Time) {
+	orchestrator := OrchestratorFromContext(ctx)
+	key := orchestrator.Namespace + "/" + orchestrator.Name + "/" + component + "/" + csv
+	if _, observed := observedCSVs.LoadOrStore(key, true); observed {
+		return
+	}
+	CSVSucceededSeconds.WithLabelValues(orchestrator.Namespace, orchestrator.Name, component, csv).
+		Observe(csvSucceeded.Sub(subscriptionCreated).Seconds())
+}
+
+// RecordReconcileError counts a failed reconciliation of the component.
+func RecordReconcileError(ctx context.Context, component string, err error) {
+	orchestrator := OrchestratorFromContext(ctx)
+	ReconcileErrors.WithLabelValues(orchestrator.Namespace, orchestrator.Name, component, ErrorReason(err)).Inc()
+}
+
+// RecordDriftCorrection counts a managed resource updated back to its desired
+// state. Updates made while the spec changed are not counted.
+func RecordDriftCorrection(ctx context.Context, component, kind string) {
+	orchestrator := OrchestratorFromContext(ctx)
+	if orchestrator.SpecChanged {
+		return
+	}
+	DriftCorrections.WithLabelValues(orchestrator.Namespace, orchestrator.Name, component, kind).Inc()
+}
+
+// DeleteOrchestrator removes the series of a deleted orchestrator.
+func DeleteOrchestrator(namespace, name string) {
+	labels := Orchestrator{Namespace: namespace, Name: name}.labels()
+	ComponentPhase.DeletePartialMatch(labels)
+	CSVSucceededSeconds.DeletePartialMatch(labels)
+	ReconcileErrors.DeletePartialMatch(labels)
+	DriftCorrections.DeletePartialMatch(labels)
+	PluginInfo.DeletePartialMatch(labels)
+	observedCSVs.Range(func(key, _ any) bool {
+		if strings.HasPrefix(key.(string), namespace+"/"+name+"/") {
+			observedCSVs.Delete(key)
+		}
+		return true
+	})
+}
+
+// PluginVersion is a Backstage plugin package deployed at a version.
+type PluginVersion struct {
+	Plugin  string
+	Package string
+	Version string
+}
+
+// SetPluginVersions replaces the plugin versions deployed by the orchestrator.
+func SetPluginVersions(ctx context.Context, plugins []PluginVersion) {
+	orchestrator := OrchestratorFromContext(ctx)
+	PluginInfo.DeletePartialMatch(orchestrator.labels())
+	for _, plugin := range plugins {
+		PluginInfo.WithLabelValues(orchestrator.Namespace, orchestrator.Name, plugin.Plugin, plugin.Package, plugin.Version).Set(1)
+	}
+}
+
+// ErrorReason returns the label value used for the reason of an error, which
+// is the kind of the error also reported as the condition reason.
+func ErrorReason(err error) string {
+	return string(kube.ErrorKindOf(err))
+}
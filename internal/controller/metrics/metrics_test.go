@@ -0,0 +1,78 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	ctx := Orchestrator{Namespace: "orchestrator", Name: "orchestrator-sample"}.IntoContext(context.Background())
+
+	It("should only flag the current phase of a component", func() {
+		SetComponentPhase(ctx, ComponentKnative, PhaseInstalling)
+		SetComponentPhase(ctx, ComponentKnative, PhaseInstalled)
+		Expect(testutil.ToFloat64(ComponentPhase.WithLabelValues("orchestrator", "orchestrator-sample", ComponentKnative, PhaseInstalled))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(ComponentPhase.WithLabelValues("orchestrator", "orchestrator-sample", ComponentKnative, PhaseInstalling))).To(Equal(0.0))
+	})
+
+	It("should observe the time to CSV succeeded once per CSV", func() {
+		created := time.Now()
+		ObserveCSVSucceeded(ctx, ComponentSonataFlow, "logic-operator.v1.33.0", created, created.Add(time.Minute))
+		ObserveCSVSucceeded(ctx, ComponentSonataFlow, "logic-operator.v1.33.0", created, created.Add(time.Hour))
+		Expect(testutil.CollectAndCount(CSVSucceededSeconds)).To(Equal(1))
+	})
+
+	It("should label reconcile errors with the error kind", func() {
+		notFound := apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "backstage")
+		RecordReconcileError(ctx, ComponentBackstage, notFound)
+		RecordReconcileError(ctx, ComponentBackstage, errors.New("boom"))
+		RecordReconcileError(ctx, ComponentBackstage, kube.NewError(kube.InvalidSpec, "bad spec"))
+		Expect(testutil.ToFloat64(ReconcileErrors.WithLabelValues("orchestrator", "orchestrator-sample", ComponentBackstage, "MissingPrerequisite"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(ReconcileErrors.WithLabelValues("orchestrator", "orchestrator-sample", ComponentBackstage, "TransientAPI"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(ReconcileErrors.WithLabelValues("orchestrator", "orchestrator-sample", ComponentBackstage, "InvalidSpec"))).To(Equal(1.0))
+	})
+
+	It("should only count the drift of an unchanged spec", func() {
+		RecordDriftCorrection(ctx, ComponentBackstage, "ConfigMap")
+		changed := Orchestrator{Namespace: "orchestrator", Name: "orchestrator-sample", SpecChanged: true}.IntoContext(context.Background())
+		RecordDriftCorrection(changed, ComponentBackstage, "ConfigMap")
+		Expect(testutil.ToFloat64(DriftCorrections.WithLabelValues("orchestrator", "orchestrator-sample", ComponentBackstage, "ConfigMap"))).To(Equal(1.0))
+	})
+
+	It("should replace the deployed plugin versions of each orchestrator", func() {
+		other := Orchestrator{Namespace: "other", Name: "orchestrator-sample"}.IntoContext(context.Background())
+		SetPluginVersions(other, []PluginVersion{{Plugin: "orchestrator", Package: "@redhat/backstage-plugin-orchestrator", Version: "1.1.0"}})
+		SetPluginVersions(ctx, []PluginVersion{{Plugin: "orchestrator", Package: "@redhat/backstage-plugin-orchestrator", Version: "1.1.0"}})
+		SetPluginVersions(ctx, []PluginVersion{{Plugin: "orchestrator", Package: "@redhat/backstage-plugin-orchestrator", Version: "1.2.0"}})
+		Expect(testutil.CollectAndCount(PluginInfo)).To(Equal(2))
+		Expect(testutil.ToFloat64(PluginInfo.WithLabelValues("orchestrator", "orchestrator-sample", "orchestrator", "@redhat/backstage-plugin-orchestrator", "1.2.0"))).To(Equal(1.0))
+
+		DeleteOrchestrator("other", "orchestrator-sample")
+		Expect(testutil.CollectAndCount(PluginInfo)).To(Equal(1))
+	})
+})
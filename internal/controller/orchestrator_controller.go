@@ -18,20 +18,31 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
-	"github.com/parodos-dev/orchestrator-operator/internal/controller/rhdh"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"strings"
+	"sync"
 	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	configv1 "github.com/openshift/api/config/v1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	olmclientset "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned"
 	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -42,32 +53,69 @@ import (
 
 // Definition to manage Orchestrator condition status.
 const (
-	TypeAvailable   string = "Available"
-	TypeProgressing string = "Progressing"
-	TypeDegrading   string = "Degrading"
+	TypeAvailable       string = "Available"
+	TypeProgressing     string = "Progressing"
+	TypeDegrading       string = "Degrading"
+	TypePluginsVerified string = "PluginsVerified"
+	TypeAirGapped       string = "AirGapped"
+	TypeSonataFlowReady string = "SonataFlowReady"
+	TypeKnativeReady    string = "KnativeReady"
+	TypeBackstageReady  string = "BackstageReady"
+	TypePostgreSQLReady string = "PostgreSQLReady"
+	TypeWorkflowsReady  string = "WorkflowsReady"
+	TypeBrokersReady    string = "BrokersReady"
+	TypeDryRun          string = "DryRun"
+	TypePaused          string = "Paused"
 )
 
+// Reasons of the Ready condition of a component whose APIs are not served
+// yet, or which is disabled.
+const (
+	ReasonWaitingForCRD = "WaitingForCRD"
+	ReasonDisabled      = "Disabled"
+)
+
+var componentConditionTypes = []string{TypePostgreSQLReady, TypeKnativeReady, TypeBrokersReady, TypeSonataFlowReady, TypeWorkflowsReady, TypeBackstageReady}
+
 const (
 	FinalizerCRCleanup = "rhdh.redhat.com/orchestrator-cleanup"
 )
 
+// Backoff of the reconciliations failing with a retryable error.
+const (
+	ReconcileBaseDelay = 5 * time.Second
+	ReconcileMaxDelay  = 5 * time.Minute
+)
+
 // OrchestratorReconciler reconciles an Orchestrator object
 type OrchestratorReconciler struct {
 	client.Client
-	OLMClient olmclientset.Clientset
+	OLMClient olmclientset.Interface
 	Scheme    *runtime.Scheme
 	Recorder  record.EventRecorder
+	// Components are the subsystems managed by the orchestrator, the
+	// DefaultComponents when nil.
+	Components *ComponentRegistry
+
+	// csvPhases holds the last CSV phase seen for each component, so CSV
+	// events are only recorded on phase changes.
+	csvPhases sync.Map
+	// watches registers the watches of the CRDs established after startup.
+	watches *dynamicWatches
 }
 
 //+kubebuilder:rbac:groups=rhdh.redhat.com,resources=orchestrators,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=rhdh.redhat.com,resources=orchestrators/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=rhdh.redhat.com,resources=orchestrators/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
 //+kubebuilder:rbac:groups=core,resources=secrets;configmaps;namespaces;events,verbs=list;get;create;delete;patch;watch;update
 //+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
 //+kubebuilder:rbac:groups=operators.coreos.com,resources=subscriptions;operatorgroups;clusterserviceversions;catalogsources,verbs=get;list;watch;create;delete;patch
 //+kubebuilder:rbac:groups=sonataflow.org,resources=sonataflows;sonataflowclusterplatforms;sonataflowplatforms,verbs=get;list;watch;create;delete;patch;update
 //+kubebuilder:rbac:groups=operator.knative.dev,resources=knativeeventings;knativeservings,verbs=get;list;watch;create;delete;patch;update
+//+kubebuilder:rbac:groups=eventing.knative.dev,resources=brokers;triggers,verbs=get;list;watch;create;delete;update
 //+kubebuilder:rbac:groups=rhdh.redhat.com,resources=backstages,verbs=get;list;watch;create;delete;patch;update
 //+kubebuilder:rbac:groups=config.openshift.io,resources=ingresses,verbs=get;list;watch
 
@@ -95,6 +143,7 @@ func (r *OrchestratorReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			// If the custom resource is not found then it usually means that it was deleted or not created
 			// In this way, we will stop the reconciliation
 			logger.Info("Orchestrator resource not found. Ignoring since object must be deleted")
+			metrics.DeleteOrchestrator(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -102,16 +151,30 @@ func (r *OrchestratorReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
+	// record the lifecycle events of the helpers on the Orchestrator resource
+	ctx = kube.NewEvents(r.Recorder, orchestrator).IntoContext(ctx)
+	// label the metrics with the orchestrator, the updates following a spec
+	// change are not counted as drift
+	ctx = metrics.Orchestrator{
+		Namespace:   orchestrator.Namespace,
+		Name:        orchestrator.Name,
+		SpecChanged: !specReconciled(orchestrator),
+	}.IntoContext(ctx)
+
 	if !orchestrator.DeletionTimestamp.IsZero() {
-		err := r.handleCleanup(ctx)
-		if err != nil {
-			return ctrl.Result{RequeueAfter: 5 * time.Minute}, err
+		if orchestrator.Spec.DryRun {
+			// nothing was applied, and the cleanup would remove operators the orchestrator didn't install
+			kube.EventsFromContext(ctx).Warning(kube.ReasonActionSkipped, "Skipped cleanup of the components: dry run")
+		} else if err := r.handleCleanup(ctx, orchestrator.Spec); err != nil {
+			return ctrl.Result{}, err
 		}
 		// Remove the finalizer to complete deletion
 		controllerutil.RemoveFinalizer(orchestrator, FinalizerCRCleanup)
 		if err := r.Update(ctx, orchestrator); err != nil {
 			return ctrl.Result{}, err
 		}
+		metrics.DeleteOrchestrator(orchestrator.Namespace, orchestrator.Name)
+		return ctrl.Result{}, nil
 	}
 
 	// Add finalizer if not present
@@ -119,338 +182,322 @@ func (r *OrchestratorReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	// Set the status to Unknown when no status is available - usually initial reconciliation.
-	if orchestrator.Status.Conditions == nil || len(orchestrator.Status.Conditions) == 0 {
-		if err := r.UpdateStatus(ctx, orchestrator, orchestratorv1alpha1.RunningPhase, metav1.Condition{
-			Type:               TypeAvailable,
-			Status:             metav1.ConditionUnknown,
-			Reason:             "Reconciling",
-			Message:            "Starting Reconciliation",
-			LastTransitionTime: metav1.Now(),
-		}); err != nil {
-			return ctrl.Result{}, err
-		}
-		// Re-fetch orchestrator Custom Resource after updating the status
-		if err := r.Get(ctx, req.NamespacedName, orchestrator); err != nil {
-			logger.Error(err, "Failed to re fetch orchestrator")
-			return ctrl.Result{}, err
-		}
+	// the components only set the status in memory, it is written in a single
+	// patch at the end so the reconciliation doesn't trigger itself
+	original := orchestrator.DeepCopy()
+	result, err := r.reconcileComponents(ctx, orchestrator)
+	if patchErr := r.patchStatus(ctx, original, orchestrator); patchErr != nil && err == nil {
+		return ctrl.Result{}, patchErr
 	}
+	return result, err
+}
 
-	// handle sonataflow
-	sonataFlowOperator := orchestrator.Spec.SonataFlowOperator
-	if err = r.reconcileSonataFlow(ctx, sonataFlowOperator, orchestrator); err != nil {
-		logger.Error(err, "Error occurred when installing SonataFlow resources")
-		_ = r.UpdateStatus(ctx, orchestrator, orchestratorv1alpha1.FailedPhase, metav1.Condition{
-			Type:    TypeDegrading,
-			Status:  metav1.ConditionFalse,
-			Reason:  "Failed to create SonataFlow Resources",
-			Message: err.Error(),
+// reconcileComponents reconciles the components of the orchestrator in order
+// and reports their outcome in the status.
+func (r *OrchestratorReconciler) reconcileComponents(
+	ctx context.Context,
+	orchestrator *orchestratorv1alpha1.Orchestrator) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	// Set the status to Unknown when no status is available - usually initial reconciliation.
+	if len(orchestrator.Status.Conditions) == 0 {
+		r.SetStatus(orchestrator, orchestratorv1alpha1.RunningPhase, metav1.Condition{
+			Type:    TypeAvailable,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "Reconciling",
+			Message: "Starting Reconciliation",
 		})
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
 	}
-	_ = r.UpdateStatus(ctx, orchestrator, orchestratorv1alpha1.CompletedPhase, metav1.Condition{
-		Type:    TypeProgressing,
-		Status:  metav1.ConditionTrue,
-		Reason:  "Created SonataFlow Resources",
-		Message: "Completed SonataFlow Reconciliation",
-	})
 
-	//handle knative
-	serverlessOperator := orchestrator.Spec.ServerlessOperator
-	if err := r.reconcileKnative(ctx, serverlessOperator); err != nil {
-		logger.Error(err, "Error occurred when installing K-Native resources")
-		_ = r.UpdateStatus(ctx, orchestrator, orchestratorv1alpha1.FailedPhase, metav1.Condition{
-			Type:    TypeDegrading,
-			Status:  metav1.ConditionFalse,
-			Reason:  "Failed to create K-Native Resources",
-			Message: err.Error(),
+	// point the generated references to the mirrors when running air-gapped
+	spec, externalRefs := applySpec(orchestrator.Spec)
+	r.reportExternalReferences(orchestrator, externalRefs)
+
+	if spec.DryRun {
+		return r.planComponents(ctx, orchestrator, spec)
+	}
+	orchestrator.Status.Plan = nil
+	meta.RemoveStatusCondition(&orchestrator.Status.Conditions, TypeDryRun)
+
+	// reconcile the components concurrently along their dependencies
+	env := r.componentEnv()
+	// the watches map the written resources back to this orchestrator
+	env.Client = kube.NewOwnerClient(env.Client, client.ObjectKeyFromObject(orchestrator))
+	components := r.components().Components()
+	nodes := make([]componentNode, 0, len(components))
+	// the conditions reported by the components running concurrently are set
+	// on the orchestrator once they are all reconciled
+	var mu sync.Mutex
+	conditions := make(map[string][]metav1.Condition, len(components))
+	for _, component := range components {
+		// terminal errors are only retried once the spec changes
+		terminalErr := terminalComponentError(orchestrator, component.ConditionType())
+		nodes = append(nodes, componentNode{
+			name:          component.Name(),
+			conditionType: component.ConditionType(),
+			dependsOn:     component.DependsOn(),
+			reconcile: func(ctx context.Context) error {
+				if componentPaused(component, spec) {
+					return observeComponent(ctx, env, component, spec)
+				}
+				if terminalErr != nil {
+					logger.Info("Skipping component until the spec changes", "Component", component.Name(), "Message", terminalErr.Error())
+					return terminalErr
+				}
+				result, err := reconcileComponent(ctx, env, component, spec)
+				mu.Lock()
+				conditions[component.Name()] = result.Conditions
+				mu.Unlock()
+				r.recordComponentMetrics(ctx, env, component, spec, ignoreMissingAPIs(err))
+				return err
+			},
 		})
-		return ctrl.Result{Requeue: true, RequeueAfter: 3 * time.Minute}, err
 	}
-	_ = r.UpdateStatus(ctx, orchestrator, orchestratorv1alpha1.CompletedPhase, metav1.Condition{
+	results, err := reconcileGraph(ctx, nodes)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	reportPaused(orchestrator, components, spec)
+	failures := []componentFailure{}
+	for _, component := range components {
+		for _, condition := range conditions[component.Name()] {
+			setCondition(orchestrator, condition)
+		}
+		if componentPaused(component, spec) {
+			reportComponentPaused(orchestrator, component.ConditionType(), component.Enabled(spec), results[component.Name()])
+			continue
+		}
+		err := reportComponentReady(ctx, orchestrator, component.ConditionType(), component.Enabled(spec), results[component.Name()])
+		if err != nil {
+			logger.Error(err, "Error occurred when reconciling component", "Component", component.Name())
+			failures = append(failures, componentFailure{component: component.Name(), err: err})
+		}
+	}
+	if len(failures) > 0 {
+		return r.reportReconcileError(orchestrator, failures)
+	}
+
+	r.SetStatus(orchestrator, componentsPhase(orchestrator), metav1.Condition{
 		Type:    TypeProgressing,
 		Status:  metav1.ConditionTrue,
-		Reason:  "Created K-Native Resources",
-		Message: "Completed K-Native Reconciliation",
+		Reason:  "Reconciled",
+		Message: "Completed components reconciliation",
 	})
-
-	// handle backstage
-	rhdhOperator := orchestrator.Spec.RhdhOperator
-	rhdhPlugins := orchestrator.Spec.RhdhPlugins
-	if err = r.reconcileBackstage(ctx, rhdhOperator, rhdhPlugins); err != nil {
-		logger.Error(err, "Error occurred when installing Backstage resources")
-		_ = r.UpdateStatus(ctx, orchestrator, orchestratorv1alpha1.FailedPhase, metav1.Condition{
+	if meta.IsStatusConditionTrue(orchestrator.Status.Conditions, TypeDegrading) {
+		r.SetStatus(orchestrator, componentsPhase(orchestrator), metav1.Condition{
 			Type:    TypeDegrading,
 			Status:  metav1.ConditionFalse,
-			Reason:  "Failed to create Backstage Resources",
-			Message: err.Error(),
+			Reason:  "Reconciled",
+			Message: "All components reconciled",
 		})
-		return ctrl.Result{Requeue: true, RequeueAfter: 3 * time.Minute}, err
 	}
-	_ = r.UpdateStatus(ctx, orchestrator, orchestratorv1alpha1.CompletedPhase, metav1.Condition{
-		Type:    TypeProgressing,
-		Status:  metav1.ConditionTrue,
-		Reason:  "Created Backstage Resources",
-		Message: "Completed Backstage Reconciliation",
-	})
-
 	return ctrl.Result{}, nil
 }
 
-func (r *OrchestratorReconciler) reconcileSonataFlow(
+// reportComponentReady sets the Ready condition of a component from the
+// outcome of its reconciliation. A component waiting for its APIs or for its
+// dependencies is not a failure of its own, so the error is not returned;
+// the components are reconciled again once the CRDs are established or the
+// dependencies recover. The missing APIs are reported as an event when the
+// component starts waiting for them. It returns the error left to handle.
+func reportComponentReady(
 	ctx context.Context,
-	sonataFlowOperator orchestratorv1alpha1.SonataFlowOperator,
-	orchestrator *orchestratorv1alpha1.Orchestrator) error {
-
-	sfLogger := log.FromContext(ctx)
-	sfLogger.Info("Starting reconciliation for SonataFlow")
-	subscriptionName := sonataFlowOperator.Subscription.Name
-	namespace := sonataFlowOperator.Subscription.Namespace
-
-	// if subscription is disabled; check if subscription exists and handle delete
-	if !sonataFlowOperator.Enabled {
-		// handle clean up
-		if err := handleSonataFlowCleanUp(ctx, r.Client, r.OLMClient); err != nil {
-			return err
-		}
-	}
-	// Subscription is enabled;
-
-	// check namespace exist
-	_, err := kube.CheckNamespaceExist(ctx, r.Client, namespace)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			sfLogger.Info("Creating namespace", "NS", namespace)
-			if err := kube.CreateNamespace(ctx, r.Client, namespace); err != nil {
-				sfLogger.Error(err, "Error occurred when creating namespace", "NS", namespace)
-				return err
-			}
-		}
-		sfLogger.Error(err, "Error occurred when checking namespace exists", "NS", namespace)
-		return err
-	}
-
-	// check if subscription exist
-	subscriptionExists, _, err := kube.CheckSubscriptionExists(ctx, r.OLMClient, namespace, subscriptionName)
-	if err != nil {
-		sfLogger.Error(err, "Error occurred when checking subscription exists", "SubscriptionName", subscriptionName)
-		return err
-	}
-	if !subscriptionExists {
-		err := kube.InstallOperatorViaSubscription(
-			ctx, r.Client, r.OLMClient,
-			kube.OpenshiftServerlessOperatorGroupName,
-			sonataFlowOperator.Subscription)
-		if err != nil {
-			sfLogger.Error(err, "Error occurred when installing operator", "SubscriptionName", subscriptionName)
-			return err
-		}
-		sfLogger.Info("Operator successfully installed via Subscription", "SubscriptionName", subscriptionName)
-	}
-
-	// subscription exists; check if CRD exists;
-	sonataFlowClusterPlatformCRD := &apiextensionsv1.CustomResourceDefinition{}
-	err = r.Get(ctx, types.NamespacedName{Name: SonataFlowClusterPlatformCRDName, Namespace: namespace}, sonataFlowClusterPlatformCRD)
-
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			// CRD does not exist
-			sfLogger.Info("CRD resource not found.", "SubscriptionName", subscriptionName, "Namespace", namespace)
-			return nil // do we want to re-attempt subscription installation?
-		}
-		sfLogger.Error(err, "Error occurred when retrieving CRD", "CRD", SonataFlowClusterPlatformCRDName)
-	}
-
-	// CRD exist; check and handle sonataflowclusterplatform CR
-	err = handleSonataFlowClusterCR(ctx, r.Client, SonataFlowClusterPlatformCRName)
-	if err != nil {
-		sfLogger.Error(err, "Error occurred when creating SonataFlowClusterCR", "CR-Name", SonataFlowClusterPlatformCRName)
-		return err
-
-	} else {
-		// create sonataflowplatform  CR
-		err = handleSonataFlowPlatformCR(ctx, r.Client, orchestrator, SonataFlowClusterPlatformCRName)
-		if err != nil {
-			sfLogger.Error(err, "Error occurred when creating SonataFlowPlatform", "CR-Name", SonataFlowClusterPlatformCRName)
-			return err
+	orchestrator *orchestratorv1alpha1.Orchestrator,
+	conditionType string,
+	enabled bool,
+	err error) error {
+	condition := metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Reconciled",
+		Message: "All resources reconciled",
+	}
+	var missingAPIs *missingAPIsError
+	var dependencyNotReady *dependencyNotReadyError
+	switch {
+	case err == nil && !enabled:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonDisabled
+		condition.Message = "Component disabled in the spec"
+	case errors.As(err, &missingAPIs):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonWaitingForCRD
+		condition.Message = err.Error()
+		previous := meta.FindStatusCondition(orchestrator.Status.Conditions, conditionType)
+		if previous == nil || previous.Reason != condition.Reason || previous.Message != condition.Message {
+			kube.EventsFromContext(ctx).Warning(kube.ReasonActionSkipped,
+				"Skipped %s resources: APIs %s are not served yet", missingAPIs.component, strings.Join(missingAPIs.apis, ", "))
 		}
-	}
-	sfLogger.Info("Successfully created SonataFlow Resources")
-	return nil
+		err = nil
+	case errors.As(err, &dependencyNotReady):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonDependencyNotReady
+		condition.Message = err.Error()
+		err = nil
+	case err != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(kube.ErrorKindOf(err))
+		condition.Message = err.Error()
+	}
+	setCondition(orchestrator, condition)
+	return err
 }
 
-func (r *OrchestratorReconciler) reconcileKnative(ctx context.Context, serverlessOperator orchestratorv1alpha1.ServerlessOperator) error {
-	knativeLogger := log.FromContext(ctx)
-	knativeLogger.Info("Starting Reconciliation for K-Native Serverless")
-
-	knativeSubscription := serverlessOperator.Subscription
-	subscriptionName := knativeSubscription.Name
-	namespace := knativeSubscription.Namespace
-
-	// if subscription is disabled; check if subscription exists and handle delete
-	if !serverlessOperator.Enabled {
-		// handle cleanup
-		if err := handleKnativeCleanUp(ctx, r.Client, r.OLMClient); err != nil {
-			return err
-		}
+// ignoreMissingAPIs returns nil for a component waiting for its APIs.
+func ignoreMissingAPIs(err error) error {
+	var missingAPIs *missingAPIsError
+	if errors.As(err, &missingAPIs) {
+		return nil
 	}
-	// Subscription is enabled;
+	return err
+}
 
-	// check namespace exist
-	_, err := kube.CheckNamespaceExist(ctx, r.Client, namespace)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			knativeLogger.Info("Creating namespace", "NS", namespace)
-			if err := kube.CreateNamespace(ctx, r.Client, namespace); err != nil {
-				knativeLogger.Error(err, "Error occurred when creating namespace", "NS", namespace)
-				return err
-			}
+// componentsPhase returns the Running phase while a component is waiting
+// for its APIs or its dependencies, and the Completed phase otherwise.
+func componentsPhase(orchestrator *orchestratorv1alpha1.Orchestrator) orchestratorv1alpha1.OrchestratorPhase {
+	for _, conditionType := range componentConditionTypes {
+		condition := meta.FindStatusCondition(orchestrator.Status.Conditions, conditionType)
+		if condition != nil && (condition.Reason == ReasonWaitingForCRD || condition.Reason == ReasonDependencyNotReady) {
+			return orchestratorv1alpha1.RunningPhase
 		}
-		knativeLogger.Error(err, "Error occurred when checking namespace exists", "NS", namespace)
-		return err
-	}
-
-	// check if subscription exists
-	subscriptionExists, _, err := kube.CheckSubscriptionExists(ctx, r.OLMClient, namespace, subscriptionName)
-	if err != nil {
-		knativeLogger.Error(err, "Error occurred when checking subscription exists", "SubscriptionName", subscriptionName)
-		return err
 	}
+	return orchestratorv1alpha1.CompletedPhase
+}
 
-	if !subscriptionExists {
-		if err := kube.InstallOperatorViaSubscription(ctx, r.Client, r.OLMClient, kube.ServerlessOperatorGroupName, knativeSubscription); err != nil {
-			knativeLogger.Error(err, "Error occurred when installing operator", "SubscriptionName", subscriptionName)
-			return err
-		}
-		knativeLogger.Info("Operator successfully installed", "SubscriptionName", subscriptionName)
-	}
+// componentFailure is the error of a component that failed to reconcile.
+type componentFailure struct {
+	component string
+	err       error
+}
 
-	// subscription exists; check if CRD exists for knative eventing;
-	err = kube.CheckCRDExists(ctx, r.Client, KnativeEventingCRDName, namespace)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			knativeLogger.Info("CRD resource not found or ready", "SubscriptionName", subscriptionName)
-			return err
+// reportReconcileError sets the Degrading condition for the failed
+// components, with the kind of the errors as reason. When all the errors
+// are terminal, the reconciliation is not requeued and the condition records
+// the generation so it is retried once the spec changes. Otherwise it is
+// requeued with the exponential backoff of the controller.
+func (r *OrchestratorReconciler) reportReconcileError(
+	orchestrator *orchestratorv1alpha1.Orchestrator,
+	failures []componentFailure) (ctrl.Result, error) {
+	kind := kube.ErrorKindOf(failures[0].err)
+	messages := make([]string, 0, len(failures))
+	errs := make([]error, 0, len(failures))
+	for _, failure := range failures {
+		// a retryable error takes precedence, so the reconciliation is retried
+		if failureKind := kube.ErrorKindOf(failure.err); kind.Terminal() && !failureKind.Terminal() {
+			kind = failureKind
 		}
-		knativeLogger.Error(err, "Error occurred when retrieving CRD", "CRD", KnativeEventingCRDName)
-		return err
+		messages = append(messages, fmt.Sprintf("Failed to reconcile %s: %v", failure.component, failure.err))
+		errs = append(errs, failure.err)
 	}
-	// CRD exist; check and handle knative eventing CR
-	if err = handleKnativeEventingCR(ctx, r.Client); err != nil {
-		knativeLogger.Error(err, "Error occurred when creating Knative EventingCR", "CR-Name", KnativeEventingNamespacedName)
-		return err
+	err := errors.Join(errs...)
+	r.SetStatus(orchestrator, orchestratorv1alpha1.FailedPhase, metav1.Condition{
+		Type:    TypeDegrading,
+		Status:  metav1.ConditionTrue,
+		Reason:  string(kind),
+		Message: strings.Join(messages, "; "),
+	})
+	if kind.Terminal() {
+		return ctrl.Result{}, reconcile.TerminalError(err)
 	}
+	return ctrl.Result{}, err
+}
 
-	// subscription exists; check if CRD exists knative serving;
-	err = kube.CheckCRDExists(ctx, r.Client, KnativeServingCRDName, namespace)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			knativeLogger.Info("CRD resource not found or ready", "SubscriptionName", subscriptionName)
-			return nil
-		}
-		knativeLogger.Error(err, "Error occurred when retrieving CRD", "CRD", KnativeServingCRDName)
-		return err
+// specReconciled returns whether all the components were reconciled for the
+// current generation of the spec.
+func specReconciled(orchestrator *orchestratorv1alpha1.Orchestrator) bool {
+	condition := meta.FindStatusCondition(orchestrator.Status.Conditions, TypeProgressing)
+	return condition != nil && condition.Reason == "Reconciled" && condition.ObservedGeneration == orchestrator.Generation
+}
 
+// terminalComponentError returns the error reported by the Ready condition of
+// a component when it is terminal for the current generation of the spec.
+func terminalComponentError(orchestrator *orchestratorv1alpha1.Orchestrator, conditionType string) error {
+	condition := meta.FindStatusCondition(orchestrator.Status.Conditions, conditionType)
+	if condition == nil || condition.Status != metav1.ConditionFalse {
+		return nil
 	}
-	// CRD exist; check and handle knative eventing CR
-	if err = handleKnativeServingCR(ctx, r.Client); err != nil {
-		knativeLogger.Error(err, "Error occurred when creating Knative ServingCR", "CR-Name", KnativeServingNamespacedName)
-		return err
+	kind := kube.ErrorKind(condition.Reason)
+	if !kind.Terminal() || condition.ObservedGeneration != orchestrator.Generation {
+		return nil
 	}
-	return nil
+	return kube.NewError(kind, "%s", condition.Message)
 }
 
-func (r *OrchestratorReconciler) reconcileBackstage(
+// recordComponentMetrics exports the install phase of the component, the time
+// its CSV took to succeed and the reconcile errors.
+func (r *OrchestratorReconciler) recordComponentMetrics(
 	ctx context.Context,
-	rhdhOperator orchestratorv1alpha1.RHDHOperator,
-	plugins orchestratorv1alpha1.RHDHPlugins) error {
+	env ComponentEnv,
+	component Component,
+	spec orchestratorv1alpha1.OrchestratorSpec,
+	reconcileErr error) {
 	logger := log.FromContext(ctx)
-	logger.Info("Starting Reconciliation for Backstage")
+	name := component.Name()
 
-	rhdhSubscription := rhdhOperator.Subscription
-	subscriptionName := rhdhSubscription.Name
-	namespace := rhdhSubscription.Namespace
-
-	// if subscription is disabled; check if subscription exists and handle delete
-	if !rhdhOperator.Enabled {
-		// check if subscription exists using olm client
-		subscriptionExists, _, err := kube.CheckSubscriptionExists(ctx, r.OLMClient, namespace, subscriptionName)
-		if err != nil {
-			logger.Error(err, "Error occurred when checking subscription exists", "SubscriptionName", subscriptionName)
-			return err
-		}
-		if subscriptionExists {
-			// deleting subscription resource
-			err = r.OLMClient.OperatorsV1alpha1().Subscriptions(namespace).Delete(ctx, subscriptionName, metav1.DeleteOptions{})
-			if err != nil {
-				logger.Error(err, "Error occurred while deleting Subscription", "SubscriptionName", subscriptionName, "Namespace", namespace)
-				//return ctrl.Result{RequeueAfter: 5 * time.Minute}, err
-				return err
-			}
-			logger.Info("Successfully deleted Subscription: %s", subscriptionName)
-			return nil
-		}
+	if reconcileErr != nil {
+		metrics.RecordReconcileError(ctx, name, reconcileErr)
+		metrics.SetComponentPhase(ctx, name, metrics.PhaseFailed)
+		return
+	}
+	if !component.Enabled(spec) {
+		metrics.SetComponentPhase(ctx, name, metrics.PhaseDisabled)
+		return
 	}
 
-	nsExist, err := kube.CheckNamespaceExist(ctx, r.Client, namespace)
+	status, err := component.Status(ctx, env, spec)
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			logger.Error(err, "Ensure namespace already exist", "NS", namespace)
-		}
-		logger.Error(err, "Error occurred when checking namespace exists", "NS", namespace)
-		return err
+		logger.Error(err, "Error occurred when reading component status for metrics", "Component", name)
 	}
-	if nsExist {
-		// Subscription is enabled; check if subscription exists
-		subscriptionExists, _, err := kube.CheckSubscriptionExists(ctx, r.OLMClient, namespace, subscriptionName)
-		if err != nil {
-			logger.Error(err, "Error occurred when checking subscription exists", "SubscriptionName", subscriptionName)
-			return err
-		}
-		if !subscriptionExists {
-			err := kube.InstallOperatorViaSubscription(
-				ctx, r.Client, r.OLMClient,
-				rhdh.BackstageOperatorGroup, rhdhSubscription)
-			if err != nil {
-				logger.Error(err, "Error occurred when installing operator", "SubscriptionName", subscriptionName)
-				return err
-			}
-			logger.Info("Operator successfully installed", "SubscriptionName", subscriptionName)
+	if status.CSV != nil {
+		r.recordCSVEvent(ctx, name, status.CSV)
+		if status.Phase == metrics.PhaseInstalled && status.CSV.Status.LastTransitionTime != nil {
+			metrics.ObserveCSVSucceeded(ctx, name, status.CSV.Name,
+				status.Subscription.CreationTimestamp.Time, status.CSV.Status.LastTransitionTime.Time)
 		}
 	}
+	metrics.SetComponentPhase(ctx, name, status.Phase)
+}
 
-	targetNamespace := rhdhSubscription.TargetNamespace
-	npmRegistry := plugins.NpmRegistry
-	clusterDomain, _ := r.getClusterDomain(ctx)
-	// create secret
-	if err := rhdh.CreateBSSecret(rhdh.RegistrySecretName, targetNamespace, npmRegistry, ctx, r.Client); err != nil {
-		return err
+// recordCSVEvent records an event when the CSV of the component succeeds or fails.
+func (r *OrchestratorReconciler) recordCSVEvent(ctx context.Context, component string, csv *operatorsv1alpha1.ClusterServiceVersion) {
+	phase := csv.Name + "/" + string(csv.Status.Phase)
+	if previous, found := r.csvPhases.Swap(component, phase); found && previous == phase {
+		return
 	}
-	// create backstage CR
-	if err := rhdh.HandleCRCreation(rhdhOperator, plugins, clusterDomain, ctx, r.Client); err != nil {
-		return err
+	switch csv.Status.Phase {
+	case operatorsv1alpha1.CSVPhaseSucceeded:
+		kube.EventsFromContext(ctx).Normal(kube.ReasonCSVSucceeded, "CSV %s/%s of %s succeeded", csv.Namespace, csv.Name, component)
+	case operatorsv1alpha1.CSVPhaseFailed:
+		kube.EventsFromContext(ctx).Warning(kube.ReasonCSVFailed, "CSV %s/%s of %s failed: %s", csv.Namespace, csv.Name, component, csv.Status.Message)
 	}
-	return nil
 }
 
-// getClusterDomain retrieves the OpenShift cluster domain from the Ingress resource
-func (r *OrchestratorReconciler) getClusterDomain(ctx context.Context) (string, error) {
-	gcdLogger := log.FromContext(ctx)
-	ingress := &configv1.Ingress{}
-	err := r.Get(ctx, client.ObjectKey{Name: "cluster"}, ingress)
-	if err != nil {
-		gcdLogger.Error(err, "Unable to retrieve OpenShift Ingress resource")
-		return "", err
+// reportExternalReferences records the references left outside the cluster by
+// the air-gapped profile in the status and the AirGapped condition.
+func (r *OrchestratorReconciler) reportExternalReferences(
+	orchestrator *orchestratorv1alpha1.Orchestrator,
+	externalRefs []string) {
+	if !orchestrator.Spec.AirGapped.Enabled {
+		orchestrator.Status.ExternalReferences = nil
+		meta.RemoveStatusCondition(&orchestrator.Status.Conditions, TypeAirGapped)
+		return
 	}
 
-	clusterDomain := ingress.Spec.Domain
-	if ingress.Spec.Domain == "" {
-		gcdLogger.Error(err, "Cluster domain not set in Ingress resource")
-		return "", err
+	orchestrator.Status.ExternalReferences = externalRefs
+	condition := metav1.Condition{
+		Type:    TypeAirGapped,
+		Status:  metav1.ConditionTrue,
+		Reason:  "NoExternalReferences",
+		Message: "All references generated by the operator point to the mirrors",
+	}
+	if len(externalRefs) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ExternalReferencesFound"
+		condition.Message = fmt.Sprintf("%d references point outside the cluster: %s",
+			len(externalRefs), strings.Join(externalRefs, "; "))
 	}
-	gcdLogger.Info("Successfully retrieved cluster domain", "Domain", clusterDomain)
-	return clusterDomain, nil
+	r.SetStatus(orchestrator, orchestrator.Status.Phase, condition)
 }
 
 func (r *OrchestratorReconciler) addFinalizers(ctx context.Context, orchestrator *orchestratorv1alpha1.Orchestrator) error {
@@ -463,31 +510,61 @@ func (r *OrchestratorReconciler) addFinalizers(ctx context.Context, orchestrator
 	return nil
 }
 
-func (r *OrchestratorReconciler) handleCleanup(ctx context.Context) error {
-	// cleanup knative
-	if err := handleKnativeCleanUp(ctx, r.Client, r.OLMClient); err != nil {
-		return err
-	}
-	// cleanup sonataflow
-	if err := handleSonataFlowCleanUp(ctx, r.Client, r.OLMClient); err != nil {
-		return err
-	}
-	// cleanup backstage
-	if err := rhdh.HandleBackstageCleanup(ctx, r.Client, r.OLMClient); err != nil {
-		return err
+// handleCleanup cleans up the components in the reverse order of the
+// registry, so the dependents go before their dependencies.
+func (r *OrchestratorReconciler) handleCleanup(ctx context.Context, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	env := r.componentEnv()
+	components := r.components().Components()
+	for i := len(components) - 1; i >= 0; i-- {
+		if componentPaused(components[i], spec) {
+			kube.EventsFromContext(ctx).Warning(kube.ReasonActionSkipped, "Skipped cleanup of %s: paused", components[i].Name())
+			continue
+		}
+		if err := components[i].Cleanup(ctx, env, spec); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// UpdateStatus sets the status of orchestrator.
-func (r *OrchestratorReconciler) UpdateStatus(ctx context.Context, orchestrator *orchestratorv1alpha1.Orchestrator, phase orchestratorv1alpha1.OrchestratorPhase, condition metav1.Condition) error {
-	logger := log.FromContext(ctx)
+// components returns the registry of the reconciled components.
+func (r *OrchestratorReconciler) components() *ComponentRegistry {
+	if r.Components == nil {
+		return DefaultComponents()
+	}
+	return r.Components
+}
+
+func (r *OrchestratorReconciler) componentEnv() ComponentEnv {
+	return ComponentEnv{Client: r.Client, OLMClient: r.OLMClient}
+}
+
+// SetStatus sets the phase and a condition of orchestrator for the current
+// generation of the spec. The status is written by patchStatus.
+func (r *OrchestratorReconciler) SetStatus(orchestrator *orchestratorv1alpha1.Orchestrator, phase orchestratorv1alpha1.OrchestratorPhase, condition metav1.Condition) {
 	orchestrator.Status.Phase = phase
+	setCondition(orchestrator, condition)
+}
+
+// setCondition sets a condition of orchestrator for the current generation
+// of the spec, leaving the phase unchanged.
+func setCondition(orchestrator *orchestratorv1alpha1.Orchestrator, condition metav1.Condition) {
+	condition.ObservedGeneration = orchestrator.Generation
 	meta.SetStatusCondition(&orchestrator.Status.Conditions, condition)
-	//orchestrator.Status.Conditions = []metav1.Condition{condition}
+}
 
-	err := r.Status().Update(ctx, orchestrator)
-	if err != nil {
+// patchStatus writes the status set during the reconciliation, if it changed
+// since the orchestrator was fetched.
+func (r *OrchestratorReconciler) patchStatus(ctx context.Context, original, orchestrator *orchestratorv1alpha1.Orchestrator) error {
+	logger := log.FromContext(ctx)
+	orchestrator.Status.ObservedGeneration = orchestrator.Generation
+	if equality.Semantic.DeepEqual(original.Status, orchestrator.Status) {
+		return nil
+	}
+	if err := r.Status().Patch(ctx, orchestrator, client.MergeFrom(original)); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
 		logger.Error(err, "Failed to update Orchestrator status")
 		return err
 	}
@@ -503,11 +580,40 @@ func (r *OrchestratorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err != nil {
 		return err
 	}
-	r.OLMClient = *olmClient
-
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&orchestratorv1alpha1.Orchestrator{}).
-		Owns(&orchestratorv1alpha1.Orchestrator{}).
-		WithOptions(controller.Options{MaxConcurrentReconciles: 2}).
-		Complete(r)
+	r.OLMClient = olmClient
+
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		// status updates don't change the generation and are filtered out;
+		// marking the resource for deletion bumps it
+		For(&orchestratorv1alpha1.Orchestrator{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// the resources of the operators are watched once their CRD is established
+		Watches(&apiextensionsv1.CustomResourceDefinition{},
+			handler.EnqueueRequestsFromMapFunc(r.mapCRD),
+			builder.WithPredicates(watchedCRDPredicate)).
+		Watches(&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.mapToOrchestrators),
+			builder.WithPredicates(createdByPredicate)).
+		// the definitions of the workflows are read from the ConfigMaps of the users
+		Watches(&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.mapWorkflowSourceToOrchestrators),
+			builder.WithPredicates(workflowSourcePredicate)).
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.mapToOrchestrators),
+			builder.WithPredicates(createdByPredicate)).
+		// the workflows are deployed once the Jobs setting up their database complete
+		Watches(&batchv1.Job{},
+			handler.EnqueueRequestsFromMapFunc(r.mapToOrchestrators),
+			builder.WithPredicates(createdByPredicate)).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: 2,
+			// back off retryable errors from seconds up to the former fixed requeue periods
+			RateLimiter: workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](
+				ReconcileBaseDelay, ReconcileMaxDelay),
+		}).
+		Build(r)
+	if err != nil {
+		return err
+	}
+	r.watches = newDynamicWatches(c, mgr.GetCache())
+	return nil
 }
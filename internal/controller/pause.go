@@ -0,0 +1,107 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReasonPaused is the reason of the Paused condition and of the Ready
+// conditions of the paused components.
+const ReasonPaused = "Paused"
+
+// componentPaused returns whether the component is paused, by its own flag or
+// by the global one.
+func componentPaused(component Component, spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.Paused || component.Paused(spec)
+}
+
+// componentPausedError is returned for a paused component which is not
+// installed, so its dependents wait for it.
+type componentPausedError struct {
+	component string
+	phase     string
+}
+
+func (e *componentPausedError) Error() string {
+	return fmt.Sprintf("%s is paused in the %s phase", e.component, e.phase)
+}
+
+// observeComponent reads the health of a paused component without changing
+// anything.
+func observeComponent(ctx context.Context, env ComponentEnv, component Component, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	if !component.Enabled(spec) {
+		return nil
+	}
+	status, err := component.Status(ctx, env, spec)
+	if err != nil {
+		return err
+	}
+	metrics.SetComponentPhase(ctx, component.Name(), status.Phase)
+	if status.Phase != metrics.PhaseInstalled {
+		return &componentPausedError{component: component.Name(), phase: status.Phase}
+	}
+	return nil
+}
+
+// reportComponentPaused sets the Ready condition of a paused component from
+// its observed health.
+func reportComponentPaused(orchestrator *orchestratorv1alpha1.Orchestrator, conditionType string, enabled bool, err error) {
+	condition := metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonPaused,
+		Message: "Reconciliation paused, the component is installed",
+	}
+	switch {
+	case !enabled:
+		condition.Status = metav1.ConditionFalse
+		condition.Message = "Reconciliation paused, the component is disabled in the spec and not cleaned up"
+	case err != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Message = "Reconciliation paused: " + err.Error()
+	}
+	setCondition(orchestrator, condition)
+}
+
+// reportPaused sets the Paused condition listing the paused components, and
+// removes it when none is paused.
+func reportPaused(orchestrator *orchestratorv1alpha1.Orchestrator, components []Component, spec orchestratorv1alpha1.OrchestratorSpec) {
+	paused := []string{}
+	for _, component := range components {
+		if componentPaused(component, spec) {
+			paused = append(paused, component.Name())
+		}
+	}
+	if len(paused) == 0 {
+		meta.RemoveStatusCondition(&orchestrator.Status.Conditions, TypePaused)
+		return
+	}
+	setCondition(orchestrator, metav1.Condition{
+		Type:    TypePaused,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonPaused,
+		Message: "Reconciliation paused for " + strings.Join(paused, ", "),
+	})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+var _ = Describe("Pause", func() {
+	ctx := context.Background()
+
+	It("should only observe the paused components", func() {
+		paused := &fakeComponent{name: "tekton", enabled: true, paused: true}
+		running := &fakeComponent{name: "argocd", enabled: true}
+		registry := &ComponentRegistry{}
+		Expect(registry.Register(paused)).To(Succeed())
+		Expect(registry.Register(running)).To(Succeed())
+		r := &OrchestratorReconciler{Recorder: record.NewFakeRecorder(10), Components: registry}
+
+		orchestrator := &orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+		_, err := r.reconcileComponents(ctx, orchestrator)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(paused.steps).To(BeEmpty())
+		Expect(running.steps).To(Equal([]string{"prerequisites", "install", "reconcile"}))
+		condition := meta.FindStatusCondition(orchestrator.Status.Conditions, paused.ConditionType())
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(ReasonPaused))
+		condition = meta.FindStatusCondition(orchestrator.Status.Conditions, TypePaused)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Message).To(Equal("Reconciliation paused for tekton"))
+
+		Expect(r.handleCleanup(ctx, orchestrator.Spec)).To(Succeed())
+		Expect(paused.steps).To(BeEmpty())
+		Expect(running.steps).To(ContainElement("cleanup"))
+	})
+
+	It("should pause all the components with the global flag", func() {
+		component := &fakeComponent{name: "tekton", enabled: true}
+		registry := &ComponentRegistry{}
+		Expect(registry.Register(component)).To(Succeed())
+		r := &OrchestratorReconciler{Recorder: record.NewFakeRecorder(10), Components: registry}
+
+		orchestrator := &orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+		orchestrator.Spec.Paused = true
+		_, err := r.reconcileComponents(ctx, orchestrator)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(component.steps).To(BeEmpty())
+		Expect(meta.IsStatusConditionTrue(orchestrator.Status.Conditions, TypePaused)).To(BeTrue())
+
+		// resuming removes the condition
+		orchestrator.Spec.Paused = false
+		_, err = r.reconcileComponents(ctx, orchestrator)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(component.steps).NotTo(BeEmpty())
+		Expect(meta.FindStatusCondition(orchestrator.Status.Conditions, TypePaused)).To(BeNil())
+	})
+})
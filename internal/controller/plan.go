@@ -0,0 +1,106 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ReasonPlanComputed is the reason of the DryRun condition once the plan of
+// the components is computed.
+const ReasonPlanComputed = "PlanComputed"
+
+// planComponents computes the actions the components would perform, without
+// applying them, and reports them in the status and as events. The
+// components are planned independently, as their dependencies are not
+// installed, and their Ready conditions are left unchanged.
+func (r *OrchestratorReconciler) planComponents(
+	ctx context.Context,
+	orchestrator *orchestratorv1alpha1.Orchestrator,
+	spec orchestratorv1alpha1.OrchestratorSpec) (ctrl.Result, error) {
+	events := kube.EventsFromContext(ctx)
+	// the events of the helpers would report actions that are not applied
+	ctx = kube.Events{}.IntoContext(ctx)
+
+	plan := kube.NewPlan()
+	env := r.componentEnv()
+	env.Client = kube.NewPlanningClient(env.Client)
+	components := r.components().Components()
+	nodes := make([]componentNode, 0, len(components))
+	for _, component := range components {
+		nodes = append(nodes, componentNode{
+			name:          component.Name(),
+			conditionType: component.ConditionType(),
+			reconcile: func(ctx context.Context) error {
+				// a paused component performs no action
+				if componentPaused(component, spec) {
+					return nil
+				}
+				_, err := reconcileComponent(plan.IntoContext(ctx, component.Name()), env, component, spec)
+				return err
+			},
+		})
+	}
+	results, err := reconcileGraph(ctx, nodes)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	actions := []orchestratorv1alpha1.PlannedAction{}
+	stopped := []string{}
+	for _, component := range components {
+		actions = append(actions, plan.Actions(component.Name())...)
+		if err := results[component.Name()]; err != nil {
+			stopped = append(stopped, fmt.Sprintf("%s: %v", component.Name(), err))
+		}
+	}
+	// the plan is recorded as events when it changes, not on every reconciliation
+	if !equality.Semantic.DeepEqual(orchestrator.Status.Plan, actions) {
+		for _, action := range actions {
+			events.Normal(kube.ReasonActionPlanned, "Would %s %s %s of %s",
+				strings.ToLower(action.Action), action.Kind, plannedObjectName(action), action.Component)
+		}
+	}
+	orchestrator.Status.Plan = actions
+
+	message := fmt.Sprintf("Planned %d actions, nothing was applied", len(actions))
+	if len(stopped) > 0 {
+		message += "; planning stopped for " + strings.Join(stopped, "; ")
+	}
+	setCondition(orchestrator, metav1.Condition{
+		Type:    TypeDryRun,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonPlanComputed,
+		Message: message,
+	})
+	return ctrl.Result{}, nil
+}
+
+func plannedObjectName(action orchestratorv1alpha1.PlannedAction) string {
+	if action.Namespace == "" {
+		return action.Name
+	}
+	return action.Namespace + "/" + action.Name
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Dry run", func() {
+	ctx := context.Background()
+
+	It("should report the planned actions without applying them", func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+		recorder := record.NewFakeRecorder(10)
+
+		registry := &ComponentRegistry{}
+		component := &fakeComponent{name: "tekton", enabled: true, created: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tekton-pipelines", Name: "pipelines-config"},
+		}}
+		Expect(registry.Register(component)).To(Succeed())
+		r := &OrchestratorReconciler{Client: k8sClient, Recorder: recorder, Components: registry}
+
+		orchestrator := &orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+		orchestrator.Spec.DryRun = true
+		ctx := kube.NewEvents(recorder, orchestrator).IntoContext(ctx)
+		_, err := r.planComponents(ctx, orchestrator, orchestrator.Spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "tekton-pipelines", Name: "pipelines-config"}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(orchestrator.Status.Plan).To(Equal([]orchestratorv1alpha1.PlannedAction{{
+			Component: "tekton",
+			Action:    kube.PlanCreate,
+			Kind:      "ConfigMap",
+			Namespace: "tekton-pipelines",
+			Name:      "pipelines-config",
+		}}))
+		Expect(meta.IsStatusConditionTrue(orchestrator.Status.Conditions, TypeDryRun)).To(BeTrue())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Would create ConfigMap tekton-pipelines/pipelines-config of tekton")))
+	})
+
+	It("should record the writes only in dry run", func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		k8sClient := kube.NewPlanningClient(fake.NewClientBuilder().WithScheme(scheme).Build())
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tekton-pipelines"}}
+
+		plan := kube.NewPlan()
+		Expect(k8sClient.Delete(plan.IntoContext(ctx, "tekton"), namespace)).To(Succeed())
+		Expect(plan.Actions("tekton")).To(HaveLen(1))
+
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(namespace), &corev1.Namespace{})).To(Succeed())
+	})
+})
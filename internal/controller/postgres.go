@@ -0,0 +1,92 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// postgreSQLComponent checks that the PostgreSQL service used for the
+// workflow persistence exists. The database itself is not managed by the
+// orchestrator.
+type postgreSQLComponent struct{}
+
+func (c *postgreSQLComponent) Name() string { return metrics.ComponentPostgreSQL }
+
+func (c *postgreSQLComponent) ConditionType() string { return TypePostgreSQLReady }
+
+func (c *postgreSQLComponent) DependsOn() []string { return nil }
+
+// Enabled returns whether SonataFlow is enabled, as only its platform
+// persists in PostgreSQL.
+func (c *postgreSQLComponent) Enabled(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.SonataFlowOperator.Enabled
+}
+
+// Paused returns false: the database is only checked, it is paused with all
+// the components.
+func (c *postgreSQLComponent) Paused(orchestratorv1alpha1.OrchestratorSpec) bool {
+	return false
+}
+
+func (c *postgreSQLComponent) Prerequisites(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	logger := log.FromContext(ctx)
+	postgres := spec.PostgresDB
+	if postgres.ServiceName == "" {
+		return nil
+	}
+
+	service := &corev1.Service{}
+	err := env.Get(ctx, types.NamespacedName{Namespace: postgres.ServiceNameSpace, Name: postgres.ServiceName}, service)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// the services are not watched, the check is retried with the backoff
+			return kube.NewError(kube.MissingPrerequisite, "PostgreSQL service %s/%s not found, checking again within %s",
+				postgres.ServiceNameSpace, postgres.ServiceName, ReconcileMaxDelay)
+		}
+		logger.Error(err, "Error occurred when retrieving PostgreSQL service", "Service", postgres.ServiceName)
+		return err
+	}
+	return nil
+}
+
+func (c *postgreSQLComponent) Install(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) error {
+	return nil
+}
+
+func (c *postgreSQLComponent) Reconcile(
+	context.Context,
+	ComponentEnv,
+	orchestratorv1alpha1.OrchestratorSpec) (ComponentResult, error) {
+	return ComponentResult{}, nil
+}
+
+func (c *postgreSQLComponent) Status(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) (ComponentStatus, error) {
+	return ComponentStatus{Phase: metrics.PhaseInstalled}, nil
+}
+
+func (c *postgreSQLComponent) Cleanup(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) error {
+	return nil
+}
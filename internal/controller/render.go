@@ -0,0 +1,247 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	olmfake "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned/fake"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// RedactedValue replaces the values of the rendered secrets.
+const RedactedValue = "<redacted>"
+
+// Render returns the manifests of the resources the components would create
+// for the orchestrator, without cluster access. The components run against
+// an in-memory cluster holding the existing objects, such as the namespace of
+// the RHDH operator, the PostgreSQL service or the secrets referenced from
+// the spec. The manifests are returned in the order they are written, with
+// the secret values redacted. The error joins the failures of the
+// components, whose manifests are left out.
+func Render(
+	ctx context.Context,
+	scheme *runtime.Scheme,
+	orchestrator *orchestratorv1alpha1.Orchestrator,
+	existing []client.Object) ([]*unstructured.Unstructured, error) {
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(staticRESTMapper(scheme)).
+		WithObjects(existing...).
+		Build()
+	rendering := &renderingClient{Client: k8sClient}
+	olmClient := olmfake.NewSimpleClientset()
+	// the subscriptions are created through the OLM clientset
+	olmClient.PrependReactor("create", "subscriptions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if create, ok := action.(k8stesting.CreateAction); ok {
+			if object, ok := create.GetObject().(client.Object); ok {
+				rendering.record(object)
+			}
+		}
+		return false, nil, nil
+	})
+	env := ComponentEnv{Client: rendering, OLMClient: olmClient}
+
+	spec, _ := applySpec(orchestrator.Spec)
+	// the plugins can't be verified against the registry offline
+	spec.RhdhPlugins.VerifyIntegrity = false
+
+	errs := []error{}
+	for _, component := range DefaultComponents().Components() {
+		if !component.Enabled(spec) {
+			continue
+		}
+		_, err := reconcileComponent(ctx, env, component, spec)
+		// the workflows waiting for the Jobs setting up their database, which
+		// the rendering client completes, are deployed on a second pass
+		var databaseNotReady *workflowDatabaseNotReadyError
+		if errors.As(err, &databaseNotReady) {
+			_, err = reconcileComponent(ctx, env, component, spec)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", component.Name(), err))
+		}
+	}
+
+	manifests := make([]*unstructured.Unstructured, 0, len(rendering.objects))
+	for _, object := range rendering.objects {
+		manifest, err := toManifest(scheme, object)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, errors.Join(errs...)
+}
+
+// renderingClient records the objects written to the in-memory cluster.
+type renderingClient struct {
+	client.Client
+	objects []client.Object
+}
+
+func (c *renderingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.record(obj)
+	// nothing runs the Jobs offline, they are completed in the in-memory
+	// cluster so the resources depending on them are rendered
+	if job, ok := obj.(*batchv1.Job); ok {
+		completed := job.DeepCopy()
+		completed.Status.Conditions = append(completed.Status.Conditions, batchv1.JobCondition{
+			Type:   batchv1.JobComplete,
+			Status: corev1.ConditionTrue,
+		})
+		return c.Client.Status().Update(ctx, completed)
+	}
+	return nil
+}
+
+func (c *renderingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.Client.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.record(obj)
+	return nil
+}
+
+func (c *renderingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Client.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	c.record(obj)
+	return nil
+}
+
+// record keeps the last version of the object at the position of its first
+// write.
+func (c *renderingClient) record(obj client.Object) {
+	object := obj.DeepCopyObject().(client.Object)
+	for i, recorded := range c.objects {
+		if reflect.TypeOf(recorded) == reflect.TypeOf(object) && client.ObjectKeyFromObject(recorded) == client.ObjectKeyFromObject(object) {
+			c.objects[i] = object
+			return
+		}
+	}
+	c.objects = append(c.objects, object)
+}
+
+// toManifest converts the object to a manifest without the fields set by the
+// cluster.
+func toManifest(scheme *runtime.Scheme, object client.Object) (*unstructured.Unstructured, error) {
+	manifest, err := toUnstructured(scheme, object)
+	if err != nil {
+		return nil, err
+	}
+	manifest.SetResourceVersion("")
+	manifest.SetManagedFields(nil)
+	unstructured.RemoveNestedField(manifest.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(manifest.Object, "status")
+	return manifest, nil
+}
+
+// toUnstructured converts the object, with the values of the secrets and the
+// literal values of the environment variables redacted.
+func toUnstructured(scheme *runtime.Scheme, object client.Object) (*unstructured.Unstructured, error) {
+	gvk, err := apiutil.GVKForObject(object, scheme)
+	if err != nil {
+		return nil, err
+	}
+	if secret, ok := object.(*corev1.Secret); ok {
+		secret = secret.DeepCopy()
+		redactSecret(secret)
+		object = secret
+	}
+	// the JSON encoding drops the empty optional fields, like the API server
+	content, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &unstructured.Unstructured{}
+	if err := json.Unmarshal(content, &manifest.Object); err != nil {
+		return nil, err
+	}
+	redactEnv(manifest.Object)
+	manifest.SetGroupVersionKind(gvk)
+	return manifest, nil
+}
+
+func redactSecret(secret *corev1.Secret) {
+	for key := range secret.Data {
+		secret.Data[key] = []byte(RedactedValue)
+	}
+	for key := range secret.StringData {
+		secret.StringData[key] = RedactedValue
+	}
+}
+
+// redactEnv replaces the literal values of the environment variables of the
+// manifest, such as the env of the containers or the extra envs of Backstage.
+// The references to secrets and config maps are kept.
+func redactEnv(field interface{}) {
+	switch field := field.(type) {
+	case map[string]interface{}:
+		for key, value := range field {
+			if vars, ok := value.([]interface{}); ok && (key == "env" || key == "envs") {
+				for _, envVar := range vars {
+					if envVar, ok := envVar.(map[string]interface{}); ok && envVar["value"] != nil {
+						envVar["value"] = RedactedValue
+					}
+				}
+			}
+			redactEnv(value)
+		}
+	case []interface{}:
+		for _, item := range field {
+			redactEnv(item)
+		}
+	}
+}
+
+// staticRESTMapper maps the kinds of the scheme, which are all served by the
+// in-memory cluster.
+func staticRESTMapper(scheme *runtime.Scheme) meta.RESTMapper {
+	clusterScoped := map[schema.GroupKind]bool{
+		{Kind: "Namespace"}: true,
+		{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: true,
+		{Group: "config.openshift.io", Kind: "Ingress"}:                   true,
+	}
+	mapper := meta.NewDefaultRESTMapper(scheme.PrioritizedVersionsAllGroups())
+	for gvk := range scheme.AllKnownTypes() {
+		scope := meta.RESTScopeNamespace
+		if clusterScoped[gvk.GroupKind()] {
+			scope = meta.RESTScopeRoot
+		}
+		mapper.Add(gvk, scope)
+	}
+	return mapper
+}
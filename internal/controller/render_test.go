@@ -0,0 +1,118 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+
+	sonataapi "github.com/apache/incubator-kie-kogito-serverless-operator/api/v1alpha08"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	knative "knative.dev/operator/pkg/apis/operator/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Render", func() {
+	ctx := context.Background()
+
+	It("should render the manifests of the enabled components", func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorsv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(knative.AddToScheme(scheme)).To(Succeed())
+
+		orchestrator := &orchestratorv1alpha1.Orchestrator{}
+		orchestrator.Spec.ServerlessOperator = orchestratorv1alpha1.ServerlessOperator{
+			Enabled: true,
+			Subscription: orchestratorv1alpha1.Subscription{
+				Namespace: KnativeSubscriptionNamespace,
+				Name:      KnativeSubscriptionName,
+				Channel:   "stable",
+			},
+		}
+		manifests, err := Render(ctx, scheme, orchestrator, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		kinds := []string{}
+		for _, manifest := range manifests {
+			kinds = append(kinds, manifest.GetKind())
+			Expect(manifest.GetResourceVersion()).To(BeEmpty())
+			Expect(manifest.Object).NotTo(HaveKey("status"))
+		}
+		Expect(kinds).To(Equal([]string{"Namespace", "OperatorGroup", "Subscription", "KnativeEventing", "KnativeServing"}))
+		Expect(manifests[2].GetNamespace()).To(Equal(KnativeSubscriptionNamespace))
+	})
+
+	It("should complete the Jobs so the workflows waiting for them are rendered", func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+		Expect(sonataapi.AddToScheme(scheme)).To(Succeed())
+
+		orchestrator := &orchestratorv1alpha1.Orchestrator{}
+		orchestrator.Spec.PostgresDB.ServiceName = "sonataflow-psql-postgresql"
+		orchestrator.Spec.Workflows = []orchestratorv1alpha1.Workflow{{
+			Name:      "greeting",
+			ConfigMap: &orchestratorv1alpha1.WorkflowConfigMapSource{Name: "greeting-workflow"},
+		}}
+		definition := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: SonataFlowNamespace, Name: "greeting-workflow"},
+			Data:       map[string]string{"greeting.sw.yaml": greetingDefinition},
+		}
+		env := ComponentEnv{Client: &renderingClient{Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRESTMapper(staticRESTMapper(scheme)).
+			WithObjects(definition).
+			Build()}}
+
+		component := &workflowsComponent{}
+		_, err := component.Reconcile(ctx, env, orchestrator.Spec)
+		var notReady *workflowDatabaseNotReadyError
+		Expect(errors.As(err, &notReady)).To(BeTrue())
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+
+		kinds := []string{}
+		for _, object := range env.Client.(*renderingClient).objects {
+			kinds = append(kinds, reflect.TypeOf(object).Elem().Name())
+		}
+		Expect(kinds).To(Equal([]string{"Secret", "Job", "SonataFlow"}))
+	})
+
+	It("should redact the secret values", func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		secret := &corev1.Secret{Data: map[string][]byte{"password": []byte("s3cr3t")}}
+
+		manifest, err := toManifest(scheme, secret)
+		Expect(err).NotTo(HaveOccurred())
+		value, _, err := unstructured.NestedString(manifest.Object, "data", "password")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(base64.StdEncoding.EncodeToString([]byte(RedactedValue))))
+	})
+})
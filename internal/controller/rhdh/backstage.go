@@ -6,14 +6,17 @@ import (
 	olmclientset "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned"
 	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
 	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
 	"github.com/parodos-dev/orchestrator-operator/internal/controller/util"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	rhdh "redhat-developer/red-hat-developer-hub-operator/api/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sort"
 )
 
 const (
@@ -23,6 +26,7 @@ const (
 	BackstageCRName                      = "backstage"
 	BackstageReplica               int32 = 1
 	RegistrySecretName                   = "dynamic-plugins-npmrc"
+	NpmrcSecretKey                       = ".npmrc"
 	AppConfigRHDHName                    = "app-config-rhdh"
 	AppConfigRHDHAuthName                = "app-config-rhdh-auth"
 	AppConfigRHDHCatalogName             = "app-config-rhdh-catalog"
@@ -36,13 +40,26 @@ var ConfigMapNameAndConfigDataKey = map[string]string{
 	AppConfigRHDHDynamicPluginName: "dynamic-plugins.yaml",
 }
 
-func CreateBSSecret(secretName string, secretNamespace, npmRegistry string,
+// HandleNpmrcSecret creates the dynamic plugins .npmrc secret and keeps it in
+// sync with the registries configured in the plugins spec.
+func HandleNpmrcSecret(secretName string, secretNamespace string,
+	plugins orchestratorv1alpha1.RHDHPlugins,
 	ctx context.Context, client client.Client) error {
 	logger := log.FromContext(ctx)
-	logger.Info("Creating Backstage NPMrc Secret")
+	logger.Info("Handling Backstage NPMrc Secret")
+
+	npmrcConfig, err := GetNpmrcConfig(plugins, secretNamespace, ctx, client)
+	if err != nil {
+		logger.Error(err, "Error occurred when resolving npmrc configuration", "Secret", secretName)
+		return err
+	}
+	npmrc, err := RenderNpmrc(npmrcConfig)
+	if err != nil {
+		return err
+	}
 
 	secret := &corev1.Secret{}
-	err := client.Get(ctx, types.NamespacedName{
+	err = client.Get(ctx, types.NamespacedName{
 		Namespace: secretNamespace,
 		Name:      secretName,
 	}, secret)
@@ -58,24 +75,42 @@ func CreateBSSecret(secretName string, secretNamespace, npmRegistry string,
 				},
 				Type: corev1.SecretTypeOpaque,
 				StringData: map[string]string{
-					".npmrc": fmt.Sprintf("registry=%s", npmRegistry),
+					NpmrcSecretKey: npmrc,
 				},
 			}
 
 			if err := client.Create(ctx, newSecret); err != nil {
 				logger.Error(err, "Error occurred when creating secret", "Secret", secretName)
+				operations.EventsFromContext(ctx).Warning(operations.ReasonResourceCreationFailed, "Failed to create Secret %s/%s: %v", secretNamespace, secretName, err)
 				return err
 			}
 			logger.Info("Successfully created secret", "Secret", secretName)
+			operations.EventsFromContext(ctx).Normal(operations.ReasonResourceCreated, "Created Secret %s/%s", secretNamespace, secretName)
 			return nil
 		}
 		logger.Error(err, "Error occurred when checking secret exist", "Secret", secretName)
 		return err
 	}
-	logger.Info("Secret already exist", "Secret", secretName)
+	if string(secret.Data[NpmrcSecretKey]) == npmrc {
+		logger.Info("Secret already up to date", "Secret", secretName)
+		return nil
+	}
+	secret.StringData = map[string]string{
+		NpmrcSecretKey: npmrc,
+	}
+	if err := client.Update(ctx, secret); err != nil {
+		logger.Error(err, "Error occurred when updating secret", "Secret", secretName)
+		operations.EventsFromContext(ctx).Warning(operations.ReasonResourceUpdateFailed, "Failed to update Secret %s/%s: %v", secretNamespace, secretName, err)
+		return err
+	}
+	logger.Info("Successfully updated secret", "Secret", secretName)
+	operations.EventsFromContext(ctx).Normal(operations.ReasonResourceUpdated, "Updated Secret %s/%s", secretNamespace, secretName)
+	metrics.RecordDriftCorrection(ctx, metrics.ComponentBackstage, "Secret")
 	return nil
 }
 
+// HandleCRCreation creates the Backstage CR and keeps the configmaps,
+// credentials and files it references in sync with the spec.
 func HandleCRCreation(
 	operator orchestratorv1alpha1.RHDHOperator,
 	pluginsDetails orchestratorv1alpha1.RHDHPlugins,
@@ -85,15 +120,22 @@ func HandleCRCreation(
 
 	bsLogger.Info("Handling Backstage resources")
 
-	bsConfigMapList := GetConfigmapList(ctx, client, clusterDomain, operator, pluginsDetails)
+	bsConfigMapList, err := GetConfigmapList(ctx, client, clusterDomain, operator, pluginsDetails)
+	if err != nil {
+		return err
+	}
+	appConfig := &rhdh.AppConfig{ConfigMaps: bsConfigMapList}
+	extraEnvs := &rhdh.ExtraEnvs{
+		Secrets: secretRefsToEnvs(BackstageSecretRefs(operator, pluginsDetails)),
+	}
+	extraFiles := catalogExtraFiles(operator)
 
-	if err := client.Get(ctx, types.NamespacedName{
+	existingCR := &rhdh.Backstage{}
+	err = client.Get(ctx, types.NamespacedName{
 		Namespace: operator.Subscription.TargetNamespace,
 		Name:      BackstageCRName,
-	}, &rhdh.Backstage{}); apierrors.IsNotFound(err) {
-		secret := rhdh.ObjectKeyRef{
-			Name: operator.SecretRef.Name,
-		}
+	}, existingCR)
+	if apierrors.IsNotFound(err) {
 		backstageCR := &rhdh.Backstage{
 			TypeMeta: metav1.TypeMeta{
 				APIVersion: BackstageAPIVersion,
@@ -106,53 +148,108 @@ func HandleCRCreation(
 			},
 			Spec: rhdh.BackstageSpec{
 				Application: &rhdh.Application{
-					AppConfig:                   &rhdh.AppConfig{ConfigMaps: bsConfigMapList},
+					AppConfig:                   appConfig,
 					DynamicPluginsConfigMapName: AppConfigRHDHDynamicPluginName,
-					ExtraEnvs: &rhdh.ExtraEnvs{
-						Secrets: []rhdh.ObjectKeyRef{secret},
-					},
-					Replicas: util.MakePointer(BackstageReplica),
+					ExtraEnvs:                   extraEnvs,
+					ExtraFiles:                  extraFiles,
+					Replicas:                    util.MakePointer(BackstageReplica),
 				},
 			},
 		}
 		if err := client.Create(ctx, backstageCR); err != nil {
 			bsLogger.Error(err, "Error occurred when creating Backstage resource")
+			operations.EventsFromContext(ctx).Warning(operations.ReasonResourceCreationFailed, "Failed to create %s %s/%s: %v",
+				BackstageKind, backstageCR.Namespace, backstageCR.Name, err)
 			return err
 		}
 		bsLogger.Info("Successfully created Backstage resource")
+		operations.EventsFromContext(ctx).Normal(operations.ReasonResourceCreated, "Created %s %s/%s", BackstageKind, backstageCR.Namespace, backstageCR.Name)
+		return nil
 	}
+	if err != nil {
+		bsLogger.Error(err, "Error occurred when retrieving Backstage resource")
+		return err
+	}
+
+	// keep the configmaps, credentials and files of the existing Backstage in sync,
+	// the replicas and the other settings are left to the user
+	if existingCR.Spec.Application == nil {
+		existingCR.Spec.Application = &rhdh.Application{}
+	}
+	application := existingCR.Spec.Application
+	if equality.Semantic.DeepEqual(application.AppConfig, appConfig) &&
+		application.DynamicPluginsConfigMapName == AppConfigRHDHDynamicPluginName &&
+		equality.Semantic.DeepEqual(application.ExtraEnvs, extraEnvs) &&
+		equality.Semantic.DeepEqual(application.ExtraFiles, extraFiles) {
+		return nil
+	}
+	application.AppConfig = appConfig
+	application.DynamicPluginsConfigMapName = AppConfigRHDHDynamicPluginName
+	application.ExtraEnvs = extraEnvs
+	application.ExtraFiles = extraFiles
+	if err := client.Update(ctx, existingCR); err != nil {
+		bsLogger.Error(err, "Error occurred when updating Backstage resource")
+		operations.EventsFromContext(ctx).Warning(operations.ReasonResourceUpdateFailed, "Failed to update %s %s/%s: %v",
+			BackstageKind, existingCR.Namespace, existingCR.Name, err)
+		return err
+	}
+	bsLogger.Info("Successfully updated Backstage resource")
+	operations.EventsFromContext(ctx).Normal(operations.ReasonResourceUpdated, "Updated %s %s/%s", BackstageKind, existingCR.Namespace, existingCR.Name)
 	return nil
 }
 
+// GetConfigmapList renders the Backstage configmaps, creates the missing ones
+// and updates the ones whose data no longer matches the spec. It returns the
+// app-config configmaps mounted into Backstage, sorted by name.
 func GetConfigmapList(ctx context.Context, client client.Client, clusterDomain string,
 	operator orchestratorv1alpha1.RHDHOperator,
-	rhdhPlugins orchestratorv1alpha1.RHDHPlugins) []rhdh.ObjectKeyRef {
+	rhdhPlugins orchestratorv1alpha1.RHDHPlugins) ([]rhdh.ObjectKeyRef, error) {
 
 	cmLogger := log.FromContext(ctx)
-	cmLogger.Info("Creating configmaps")
+	cmLogger.Info("Handling configmaps")
+
+	cmNames := make([]string, 0, len(ConfigMapNameAndConfigDataKey))
+	for cmName := range ConfigMapNameAndConfigDataKey {
+		cmNames = append(cmNames, cmName)
+	}
+	sort.Strings(cmNames)
 
 	configmapList := make([]rhdh.ObjectKeyRef, 0)
 	namespace := operator.Subscription.TargetNamespace
-	for cmName, configDataKey := range ConfigMapNameAndConfigDataKey {
-		if err := client.Get(ctx, types.NamespacedName{
-			Namespace: namespace,
-			Name:      cmName,
-		}, &corev1.ConfigMap{}); apierrors.IsNotFound(err) {
-			configValue, err := ConfigMapTemplateFactory(cmName, clusterDomain, operator, rhdhPlugins)
-			if err != nil {
-				cmLogger.Error(err, "Error occurred when parsing config data for configmap", "CM", cmName)
-				continue
-			} else {
-				if err := CreateConfigMap(cmName, configDataKey, namespace, configValue, ctx, client); err == nil {
-					if cmName != AppConfigRHDHDynamicPluginName {
-						configmapList = append(configmapList, rhdh.ObjectKeyRef{Name: cmName})
-					}
-				}
-			}
+	for _, cmName := range cmNames {
+		configDataKey := ConfigMapNameAndConfigDataKey[cmName]
+		configValue, err := ConfigMapTemplateFactory(cmName, clusterDomain, operator, rhdhPlugins)
+		if err != nil {
+			cmLogger.Error(err, "Error occurred when parsing config data for configmap", "CM", cmName)
+			return nil, operations.WrapError(operations.InvalidSpec, fmt.Errorf("failed to render configmap %s: %w", cmName, err))
 		}
 
+		configMap := &corev1.ConfigMap{}
+		err = client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: cmName}, configMap)
+		switch {
+		case apierrors.IsNotFound(err):
+			if err := CreateConfigMap(cmName, configDataKey, namespace, configValue, ctx, client); err != nil {
+				return nil, err
+			}
+		case err != nil:
+			cmLogger.Error(err, "Error occurred when checking ConfigMap exist", "CM", cmName)
+			return nil, err
+		case configMap.Data[configDataKey] != configValue:
+			configMap.Data = map[string]string{configDataKey: configValue}
+			if err := client.Update(ctx, configMap); err != nil {
+				cmLogger.Error(err, "Error occurred when updating ConfigMap", "CM", cmName)
+				operations.EventsFromContext(ctx).Warning(operations.ReasonResourceUpdateFailed, "Failed to update ConfigMap %s/%s: %v", namespace, cmName, err)
+				return nil, err
+			}
+			cmLogger.Info("Successfully updated ConfigMap", "CM", cmName)
+			operations.EventsFromContext(ctx).Normal(operations.ReasonResourceUpdated, "Updated ConfigMap %s/%s", namespace, cmName)
+			metrics.RecordDriftCorrection(ctx, metrics.ComponentBackstage, "ConfigMap")
+		}
+		if cmName != AppConfigRHDHDynamicPluginName {
+			configmapList = append(configmapList, rhdh.ObjectKeyRef{Name: cmName})
+		}
 	}
-	return configmapList
+	return configmapList, nil
 }
 
 func CreateConfigMap(
@@ -174,13 +271,15 @@ func CreateConfigMap(
 	}
 	if err := client.Create(ctx, configMap); err != nil {
 		logger.Error(err, "Error occurred when creating ConfigMap", "CM", name)
+		operations.EventsFromContext(ctx).Warning(operations.ReasonResourceCreationFailed, "Failed to create ConfigMap %s/%s: %v", namespace, name, err)
 		return err
 	}
 	logger.Info("Successfully created ConfigMap", "CM", name)
+	operations.EventsFromContext(ctx).Normal(operations.ReasonResourceCreated, "Created ConfigMap %s/%s", namespace, name)
 	return nil
 }
 
-func HandleBackstageCleanup(ctx context.Context, client client.Client, olmClientSet olmclientset.Clientset) error {
+func HandleBackstageCleanup(ctx context.Context, client client.Client, olmClientSet olmclientset.Interface) error {
 	logger := log.FromContext(ctx)
 
 	rhdhNamespace := "rhdh-operator" // remove hardcoded TODO
@@ -192,6 +291,10 @@ func HandleBackstageCleanup(ctx context.Context, client client.Client, olmClient
 
 		if err != nil || len(backstageCRList) == 0 {
 			logger.Error(err, "Failed to list backstage CRs or have no Backstage CRs created by Orchestrator Operator and cannot perform clean up process")
+			if err == nil {
+				operations.EventsFromContext(ctx).Warning(operations.ReasonActionSkipped,
+					"Skipped cleanup of namespace %s: no Backstage resources created by the orchestrator", rhdhNamespace)
+			}
 			return err
 		}
 		if len(backstageCRList) == 1 {
@@ -0,0 +1,94 @@
+package rhdh
+
+import (
+	"context"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	rhdh "redhat-developer/red-hat-developer-hub-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backstage resource", func() {
+	ctx := context.Background()
+	var k8sClient client.Client
+	var operator orchestratorv1alpha1.RHDHOperator
+	plugins := orchestratorv1alpha1.RHDHPlugins{}
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(rhdh.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).Build()
+		operator = orchestratorv1alpha1.RHDHOperator{
+			Subscription: orchestratorv1alpha1.Subscription{TargetNamespace: "rhdh-operator"},
+			SecretRef: orchestratorv1alpha1.SecretRefBS{
+				Name:      "backstage-backend-auth-secret",
+				Backstage: orchestratorv1alpha1.BackstageSecret{BackendSecret: orchestratorv1alpha1.SecretKeySelector{Key: "BACKEND_SECRET"}},
+			},
+		}
+	})
+
+	getBackstage := func() *rhdh.Backstage {
+		backstage := &rhdh.Backstage{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "rhdh-operator", Name: BackstageCRName}, backstage)).To(Succeed())
+		return backstage
+	}
+
+	It("should sync the secret references into the existing Backstage", func() {
+		Expect(HandleCRCreation(operator, plugins, "example.com", ctx, k8sClient)).To(Succeed())
+		Expect(getBackstage().Spec.Application.ExtraEnvs.Secrets).To(Equal([]rhdh.ObjectKeyRef{
+			{Name: "backstage-backend-auth-secret", Key: "BACKEND_SECRET"},
+		}))
+
+		operator.SecretRef.Github.Token = orchestratorv1alpha1.SecretKeySelector{Name: "github-credentials", Key: "GITHUB_TOKEN"}
+		Expect(HandleCRCreation(operator, plugins, "example.com", ctx, k8sClient)).To(Succeed())
+		backstage := getBackstage()
+		Expect(backstage.Spec.Application.ExtraEnvs.Secrets).To(Equal([]rhdh.ObjectKeyRef{
+			{Name: "backstage-backend-auth-secret", Key: "BACKEND_SECRET"},
+			{Name: "github-credentials", Key: "GITHUB_TOKEN"},
+		}))
+		Expect(backstage.Spec.Application.DynamicPluginsConfigMapName).To(Equal(AppConfigRHDHDynamicPluginName))
+	})
+	It("should update the rendered configmaps of an existing Backstage", func() {
+		Expect(HandleCRCreation(operator, plugins, "example.com", ctx, k8sClient)).To(Succeed())
+		Expect(getBackstage().Spec.Application.AppConfig.ConfigMaps).To(Equal([]rhdh.ObjectKeyRef{
+			{Name: AppConfigRHDHName}, {Name: AppConfigRHDHAuthName}, {Name: AppConfigRHDHCatalogName},
+		}))
+
+		operator.EnableGuestProvider = true
+		Expect(HandleCRCreation(operator, plugins, "example.com", ctx, k8sClient)).To(Succeed())
+		configMap := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "rhdh-operator", Name: AppConfigRHDHAuthName}, configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKeyWithValue(ConfigMapNameAndConfigDataKey[AppConfigRHDHAuthName], ContainSubstring("guest:")))
+		Expect(getBackstage().Spec.Application.AppConfig.ConfigMaps).To(HaveLen(3))
+	})
+
+	It("should report a configuration that can't be rendered as an invalid spec", func() {
+		operator.Auth.Providers = []orchestratorv1alpha1.AuthProvider{
+			{Type: orchestratorv1alpha1.GuestAuthProvider}, {Type: orchestratorv1alpha1.GuestAuthProvider},
+		}
+		err := HandleCRCreation(operator, plugins, "example.com", ctx, k8sClient)
+		Expect(operations.IsTerminal(err)).To(BeTrue())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "rhdh-operator", Name: BackstageCRName}, &rhdh.Backstage{})).NotTo(Succeed())
+	})
+	It("should mount the templates configmap into the existing Backstage", func() {
+		Expect(HandleCRCreation(operator, plugins, "example.com", ctx, k8sClient)).To(Succeed())
+		Expect(getBackstage().Spec.Application.ExtraFiles).To(BeNil())
+
+		operator.Catalog.TemplatesConfigMap = "workflow-templates"
+		Expect(HandleCRCreation(operator, plugins, "example.com", ctx, k8sClient)).To(Succeed())
+		Expect(getBackstage().Spec.Application.ExtraFiles).To(Equal(&rhdh.ExtraFiles{
+			MountPath:  CatalogEntitiesMountPath,
+			ConfigMaps: []rhdh.ObjectKeyRef{{Name: "workflow-templates"}},
+		}))
+	})
+})
@@ -0,0 +1,53 @@
+package rhdh
+
+import (
+	"context"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	rhdh "redhat-developer/red-hat-developer-hub-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// CatalogEntitiesMountPath is where the configmap of the workflow templates is
+// mounted into Backstage.
+const CatalogEntitiesMountPath = "/opt/app-root/src/catalog-entities/orchestrator"
+
+// ValidateTemplatesConfigMap checks that the configmap of the workflow
+// templates mounted into Backstage exists.
+func ValidateTemplatesConfigMap(
+	operator orchestratorv1alpha1.RHDHOperator,
+	ctx context.Context, client client.Client) error {
+	logger := log.FromContext(ctx)
+
+	configMapName := operator.Catalog.TemplatesConfigMap
+	if configMapName == "" {
+		return nil
+	}
+	namespace := operator.Subscription.TargetNamespace
+	err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: configMapName}, &corev1.ConfigMap{})
+	if apierrors.IsNotFound(err) {
+		return operations.NewError(operations.MissingPrerequisite, "configmap %s/%s of the workflow templates does not exist", namespace, configMapName)
+	}
+	if err != nil {
+		logger.Error(err, "Error occurred when retrieving ConfigMap", "CM", configMapName)
+		return err
+	}
+	return nil
+}
+
+// catalogExtraFiles returns the files mounted into Backstage, the configmap
+// of the workflow templates when one is set.
+func catalogExtraFiles(operator orchestratorv1alpha1.RHDHOperator) *rhdh.ExtraFiles {
+	if operator.Catalog.TemplatesConfigMap == "" {
+		return nil
+	}
+	return &rhdh.ExtraFiles{
+		MountPath:  CatalogEntitiesMountPath,
+		ConfigMaps: []rhdh.ObjectKeyRef{{Name: operator.Catalog.TemplatesConfigMap}},
+	}
+}
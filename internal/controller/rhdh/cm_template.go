@@ -14,11 +14,11 @@ func ConfigMapTemplateFactory(
 	case AppConfigRHDHName:
 		configData := RHDHConfig{
 			TargetNamespace: operator.Subscription.TargetNamespace,
-			ArgoCDUsername:  operator.SecretRef.ArgoCD.Username,
-			ArgoCDPassword:  operator.SecretRef.ArgoCD.Password,
-			ArgoCDUrl:       operator.SecretRef.ArgoCD.Url,
+			ArgoCDUsername:  newSecretKeyRef(operator, operator.SecretRef.ArgoCD.Username),
+			ArgoCDPassword:  newSecretKeyRef(operator, operator.SecretRef.ArgoCD.Password),
+			ArgoCDUrl:       newSecretKeyRef(operator, operator.SecretRef.ArgoCD.Url),
 			ArgoCDEnabled:   operator.SecretRef.ArgoCD.Enabled,
-			BackendSecret:   operator.SecretRef.Backstage.BackendSecret,
+			BackendSecret:   newSecretKeyRef(operator, operator.SecretRef.Backstage.BackendSecret),
 			ClusterDomain:   clusterDomain,
 		}
 		formattedConfig, err := parseConfigTemplate(RHDHConfigTempl, configData)
@@ -27,12 +27,17 @@ func ConfigMapTemplateFactory(
 		}
 		return formattedConfig, nil
 	case AppConfigRHDHAuthName:
+		if err := validateIntegrations(operator); err != nil {
+			return "", err
+		}
+		if err := validateAuthProviders(operator); err != nil {
+			return "", err
+		}
 		configData := RHDHConfigAuth{
-			GitHubToken:         operator.SecretRef.Github.Token,
-			Environment:         "development",
-			GitHubClientId:      operator.SecretRef.Github.ClientID,
-			GitHubClientSecret:  operator.SecretRef.Github.ClientSecret,
-			EnableGuestProvider: operator.EnableGuestProvider,
+			Integrations: getIntegrations(operator),
+			Environment:  getAuthEnvironment(operator),
+			SignInPage:   operator.Auth.SignInPage,
+			Providers:    getAuthProviders(operator),
 		}
 		formattedConfig, err := parseConfigTemplate(RHDHAuthTempl, configData)
 		if err != nil {
@@ -41,8 +46,8 @@ func ConfigMapTemplateFactory(
 		return formattedConfig, nil
 	case AppConfigRHDHCatalogName:
 		configData := RHDHConfigCatalog{
-			EnableGuestProvider: operator.EnableGuestProvider,
-			CatalogBranch:       operator.CatalogBranch,
+			Rules:     getCatalogRules(operator),
+			Locations: getCatalogLocations(operator),
 		}
 		formattedConfig, err := parseConfigTemplate(RHDHCatalogTempl, configData)
 		if err != nil {
@@ -52,13 +57,13 @@ func ConfigMapTemplateFactory(
 	case AppConfigRHDHDynamicPluginName:
 		pluginsMap := getPlugins()
 		configData := RHDHDynamicPluginConfig{
-			K8ClusterToken:               operator.SecretRef.ClusterTokenUrl.ClusterToken,
-			K8ClusterUrl:                 operator.SecretRef.ClusterTokenUrl.ClusterUrl,
+			K8ClusterToken:               newSecretKeyRef(operator, operator.SecretRef.ClusterTokenUrl.ClusterToken),
+			K8ClusterUrl:                 newSecretKeyRef(operator, operator.SecretRef.ClusterTokenUrl.ClusterUrl),
 			TektonEnabled:                false,
 			ArgoCDEnabled:                operator.SecretRef.ArgoCD.Enabled,
-			ArgoCDUrl:                    operator.SecretRef.ArgoCD.Url,
-			ArgoCDUsername:               operator.SecretRef.ArgoCD.Username,
-			ArgoCDPassword:               operator.SecretRef.ArgoCD.Password,
+			ArgoCDUrl:                    newSecretKeyRef(operator, operator.SecretRef.ArgoCD.Url),
+			ArgoCDUsername:               newSecretKeyRef(operator, operator.SecretRef.ArgoCD.Username),
+			ArgoCDPassword:               newSecretKeyRef(operator, operator.SecretRef.ArgoCD.Password),
 			OrchestratorBackendPackage:   pluginsMap[OrchestratorBackend].Package,
 			OrchestratorBackendIntegrity: pluginsMap[OrchestratorBackend].Integrity,
 			OrchestratorPackage:          pluginsMap[Orchestrator].Package,
@@ -75,13 +80,14 @@ func ConfigMapTemplateFactory(
 			NotificationEmailPackage:     pluginsMap[NotificationsEmail].Package,
 			NotificationEmailIntegrity:   pluginsMap[NotificationsEmail].Integrity,
 			NotificationEmailEnabled:     plugins.NotificationsConfig.Enabled,
-			NotificationEmailHostname:    operator.SecretRef.NotificationsEmail.Hostname,
-			NotificationEmailUsername:    operator.SecretRef.NotificationsEmail.Username,
-			NotificationEmailPassword:    operator.SecretRef.NotificationsEmail.Password,
+			NotificationEmailHostname:    newSecretKeyRef(operator, operator.SecretRef.NotificationsEmail.Hostname),
+			NotificationEmailUsername:    newSecretKeyRef(operator, operator.SecretRef.NotificationsEmail.Username),
+			NotificationEmailPassword:    newSecretKeyRef(operator, operator.SecretRef.NotificationsEmail.Password),
 			NotificationEmailSender:      plugins.NotificationsConfig.Sender,
 			NotificationEmailReplyTo:     plugins.NotificationsConfig.Recipient,
 			NotificationEmailPort:        plugins.NotificationsConfig.Port,
 			WorkflowNamespace:            "sonataflow-infra",
+			ScaffolderModules:            getIntegrations(operator).scaffolderModules(),
 		}
 		formattedConfig, err := parseConfigTemplate(RHDHDynamicPluginTempl, configData)
 		if err != nil {
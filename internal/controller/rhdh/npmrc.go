@@ -0,0 +1,161 @@
+package rhdh
+
+import (
+	"context"
+	"encoding/pem"
+	"net/url"
+	"strings"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const NpmrcTempl = `registry={{ .Registry.URL }}
+{{- if .Registry.Token }}
+{{ .Registry.AuthKey }}:_authToken={{ .Registry.Token }}
+{{- end }}
+{{- range .ScopedRegistries }}
+{{ .Scope }}:registry={{ .URL }}
+{{- if .Token }}
+{{ .AuthKey }}:_authToken={{ .Token }}
+{{- end }}
+{{- end }}
+{{- range .CACerts }}
+ca[]="{{ . }}"
+{{- end }}
+{{- if .HttpProxy }}
+proxy={{ .HttpProxy }}
+{{- end }}
+{{- if .HttpsProxy }}
+https-proxy={{ .HttpsProxy }}
+{{- end }}
+{{- if .NoProxy }}
+noproxy={{ .NoProxy }}
+{{- end }}
+`
+
+type NpmrcRegistry struct {
+	Scope   string
+	URL     string
+	AuthKey string
+	Token   string
+}
+
+type NpmrcConfig struct {
+	Registry         NpmrcRegistry
+	ScopedRegistries []NpmrcRegistry
+	CACerts          []string
+	HttpProxy        string
+	HttpsProxy       string
+	NoProxy          string
+}
+
+// GetNpmrcConfig resolves the registries, tokens and CA bundle referenced by
+// the plugins configuration. Referenced secrets and configmaps are read from
+// the Backstage namespace.
+func GetNpmrcConfig(
+	plugins orchestratorv1alpha1.RHDHPlugins, namespace string,
+	ctx context.Context, client client.Client) (NpmrcConfig, error) {
+
+	tokens := map[string]string{}
+	if plugins.NpmAuthSecret.Name != "" {
+		secret := &corev1.Secret{}
+		if err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: plugins.NpmAuthSecret.Name}, secret); err != nil {
+			return NpmrcConfig{}, err
+		}
+		for key, value := range secret.Data {
+			tokens[key] = string(value)
+		}
+	}
+	lookupToken := func(key string) (string, error) {
+		if key == "" {
+			return "", nil
+		}
+		token, ok := tokens[key]
+		if !ok {
+			return "", operations.NewError(operations.MissingPrerequisite, "key %s not found in NPM auth secret %s", key, plugins.NpmAuthSecret.Name)
+		}
+		return token, nil
+	}
+
+	token, err := lookupToken(plugins.NpmAuthSecret.TokenKey)
+	if err != nil {
+		return NpmrcConfig{}, err
+	}
+	config := NpmrcConfig{
+		Registry:   NpmrcRegistry{URL: plugins.NpmRegistry, AuthKey: npmrcAuthKey(plugins.NpmRegistry), Token: token},
+		HttpProxy:  plugins.NpmProxy.HttpProxy,
+		HttpsProxy: plugins.NpmProxy.HttpsProxy,
+		NoProxy:    plugins.NpmProxy.NoProxy,
+	}
+	for _, scoped := range plugins.ScopedRegistries {
+		token, err := lookupToken(scoped.TokenKey)
+		if err != nil {
+			return NpmrcConfig{}, err
+		}
+		config.ScopedRegistries = append(config.ScopedRegistries, NpmrcRegistry{
+			Scope:   scoped.Scope,
+			URL:     scoped.Registry,
+			AuthKey: npmrcAuthKey(scoped.Registry),
+			Token:   token,
+		})
+	}
+
+	if plugins.NpmCABundle.ConfigMapName != "" {
+		configMap := &corev1.ConfigMap{}
+		if err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: plugins.NpmCABundle.ConfigMapName}, configMap); err != nil {
+			return NpmrcConfig{}, err
+		}
+		bundle, ok := configMap.Data[plugins.NpmCABundle.Key]
+		if !ok {
+			return NpmrcConfig{}, operations.NewError(operations.MissingPrerequisite, "key %s not found in CA bundle configmap %s", plugins.NpmCABundle.Key, plugins.NpmCABundle.ConfigMapName)
+		}
+		config.CACerts = splitCABundle(bundle)
+	}
+	return config, nil
+}
+
+// RenderNpmrc renders the .npmrc used by the dynamic plugins installer.
+func RenderNpmrc(config NpmrcConfig) (string, error) {
+	return parseConfigTemplate(NpmrcTempl, config)
+}
+
+// PluginRegistry returns the registry the plugins scope is resolved from.
+func PluginRegistry(plugins orchestratorv1alpha1.RHDHPlugins) string {
+	for _, scoped := range plugins.ScopedRegistries {
+		if scoped.Scope == plugins.Scope {
+			return scoped.Registry
+		}
+	}
+	return plugins.NpmRegistry
+}
+
+// npmrcAuthKey returns the registry URL without its scheme, which is how npm
+// keys per-registry credentials.
+func npmrcAuthKey(registry string) string {
+	registryURL, err := url.Parse(registry)
+	if err != nil || registryURL.Host == "" {
+		return ""
+	}
+	return "//" + registryURL.Host + strings.TrimSuffix(registryURL.Path, "/") + "/"
+}
+
+// splitCABundle splits a PEM bundle into single certificates with escaped
+// newlines, as expected by the ca[] entries of the .npmrc.
+func splitCABundle(bundle string) []string {
+	certs := make([]string, 0)
+	rest := []byte(bundle)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert := strings.TrimSpace(string(pem.EncodeToMemory(block)))
+		certs = append(certs, strings.ReplaceAll(cert, "\n", `\n`))
+	}
+	return certs
+}
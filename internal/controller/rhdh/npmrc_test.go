@@ -0,0 +1,84 @@
+package rhdh
+
+import (
+	"context"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const testCABundle = `-----BEGIN CERTIFICATE-----
+MIIBszCCAVmgAwIBAgIUQ0ZmYWtl
+-----END CERTIFICATE-----
+-----BEGIN CERTIFICATE-----
+MIIBszCCAVmgAwIBAgIUQ0ZmYWts
+-----END CERTIFICATE-----
+`
+
+var _ = Describe("NPMrc rendering", func() {
+	ctx := context.Background()
+	namespace := "rhdh-operator"
+
+	plugins := orchestratorv1alpha1.RHDHPlugins{
+		NpmRegistry:   "https://npm.registry.redhat.com",
+		Scope:         "@internal",
+		NpmAuthSecret: orchestratorv1alpha1.NpmAuthSecret{Name: "npm-auth", TokenKey: "REDHAT_TOKEN"},
+		ScopedRegistries: []orchestratorv1alpha1.ScopedNpmRegistry{
+			{Scope: "@internal", Registry: "https://nexus.example.com/repository/npm/", TokenKey: "NEXUS_TOKEN"},
+		},
+		NpmCABundle: orchestratorv1alpha1.NpmCABundle{ConfigMapName: "npm-ca", Key: "ca.crt"},
+		NpmProxy:    orchestratorv1alpha1.NpmProxy{HttpsProxy: "http://proxy.example.com:3128", NoProxy: ".svc"},
+	}
+
+	authSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "npm-auth", Namespace: namespace},
+		Data:       map[string][]byte{"REDHAT_TOKEN": []byte("rh-token"), "NEXUS_TOKEN": []byte("nexus-token")},
+	}
+	caConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "npm-ca", Namespace: namespace},
+		Data:       map[string]string{"ca.crt": testCABundle},
+	}
+
+	It("should render registries, tokens, CA certificates and proxies", func() {
+		k8sClient := fake.NewClientBuilder().WithObjects(authSecret, caConfigMap).Build()
+
+		config, err := GetNpmrcConfig(plugins, namespace, ctx, k8sClient)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(PluginRegistry(plugins)).To(Equal("https://nexus.example.com/repository/npm/"))
+
+		npmrc, err := RenderNpmrc(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(npmrc).To(Equal(`registry=https://npm.registry.redhat.com
+//npm.registry.redhat.com/:_authToken=rh-token
+@internal:registry=https://nexus.example.com/repository/npm/
+//nexus.example.com/repository/npm/:_authToken=nexus-token
+ca[]="-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIUQ0ZmYWtl\n-----END CERTIFICATE-----"
+ca[]="-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIUQ0ZmYWts\n-----END CERTIFICATE-----"
+https-proxy=http://proxy.example.com:3128
+noproxy=.svc
+`))
+	})
+
+	It("should fail when a referenced token key is missing", func() {
+		k8sClient := fake.NewClientBuilder().WithObjects(authSecret, caConfigMap).Build()
+		missing := plugins
+		missing.NpmAuthSecret.TokenKey = "MISSING"
+
+		_, err := GetNpmrcConfig(missing, namespace, ctx, k8sClient)
+		Expect(err).To(MatchError(ContainSubstring("MISSING")))
+		Expect(operations.ErrorKindOf(err)).To(Equal(operations.MissingPrerequisite))
+	})
+
+	It("should keep the plain registry when nothing else is configured", func() {
+		config, err := GetNpmrcConfig(orchestratorv1alpha1.RHDHPlugins{NpmRegistry: "https://npm.registry.redhat.com"},
+			namespace, ctx, fake.NewClientBuilder().Build())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(RenderNpmrc(config)).To(Equal("registry=https://npm.registry.redhat.com\n"))
+	})
+})
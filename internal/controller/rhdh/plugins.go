@@ -1,5 +1,12 @@
 package rhdh
 
+import (
+	"sort"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
+)
+
 type Plugin struct {
 	Package   string
 	Integrity string
@@ -46,3 +53,31 @@ func getPlugins() map[string]Plugin {
 	}
 
 }
+
+// enabledPlugins returns the plugins rendered into the dynamic plugins
+// configuration. The email notifications are only deployed when enabled with
+// the hostname of the mail server.
+func enabledPlugins(operator orchestratorv1alpha1.RHDHOperator, plugins orchestratorv1alpha1.RHDHPlugins) map[string]Plugin {
+	enabled := getPlugins()
+	if !plugins.NotificationsConfig.Enabled || operator.SecretRef.NotificationsEmail.Hostname.Key == "" {
+		delete(enabled, NotificationsEmail)
+	}
+	return enabled
+}
+
+// PluginVersions returns the package and version of each plugin deployed
+// from the plugins scope, sorted by plugin name.
+func PluginVersions(scope string) []metrics.PluginVersion {
+	plugins := getPlugins()
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	versions := make([]metrics.PluginVersion, 0, len(names))
+	for _, name := range names {
+		pkg, version := splitPackageVersion(plugins[name].Package)
+		versions = append(versions, metrics.PluginVersion{Plugin: name, Package: scope + "/" + pkg, Version: version})
+	}
+	return versions
+}
@@ -0,0 +1,190 @@
+package rhdh
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// PluginVerificationClient is the HTTP client used to query the NPM registry.
+var PluginVerificationClient = &http.Client{Timeout: 30 * time.Second}
+
+// PluginIntegrityMismatch describes a plugin whose configured integrity
+// differs from the one published in the registry. Published is empty when
+// the package version could not be found in the registry.
+type PluginIntegrityMismatch struct {
+	Plugin    string
+	Package   string
+	Expected  string
+	Published string
+}
+
+func (m PluginIntegrityMismatch) String() string {
+	if m.Published == "" {
+		return fmt.Sprintf("%s (%s): not found in registry", m.Plugin, m.Package)
+	}
+	return fmt.Sprintf("%s (%s): expected %s, registry has %s", m.Plugin, m.Package, m.Expected, m.Published)
+}
+
+type npmPackument struct {
+	Versions map[string]struct {
+		Dist struct {
+			Integrity string `json:"integrity"`
+		} `json:"dist"`
+	} `json:"versions"`
+}
+
+// VerifyPluginIntegrity compares the integrity of each enabled orchestrator
+// plugin with the dist.integrity published in the NPM registry. The .npmrc
+// content is used to authenticate against the registry.
+func VerifyPluginIntegrity(ctx context.Context, httpClient *http.Client,
+	operator orchestratorv1alpha1.RHDHOperator, plugins orchestratorv1alpha1.RHDHPlugins,
+	npmRegistry, npmrc string) ([]PluginIntegrityMismatch, error) {
+	return verifyPlugins(ctx, httpClient, npmRegistry, plugins.Scope, npmrc, enabledPlugins(operator, plugins))
+}
+
+func verifyPlugins(ctx context.Context, httpClient *http.Client,
+	npmRegistry, scope, npmrc string, plugins map[string]Plugin) ([]PluginIntegrityMismatch, error) {
+	logger := log.FromContext(ctx)
+
+	// iterate in a stable order so that the reported mismatches do not change between reconciliations
+	pluginNames := make([]string, 0, len(plugins))
+	for name := range plugins {
+		pluginNames = append(pluginNames, name)
+	}
+	sort.Strings(pluginNames)
+
+	authHeader := npmrcAuthHeader(npmrc, npmRegistry)
+	mismatches := make([]PluginIntegrityMismatch, 0)
+	for _, name := range pluginNames {
+		plugin := plugins[name]
+		packageName, version := splitPackageVersion(plugin.Package)
+		if scope != "" {
+			packageName = scope + "/" + packageName
+		}
+		published, err := getPublishedIntegrity(ctx, httpClient, npmRegistry, authHeader, packageName, version)
+		if err != nil {
+			logger.Error(err, "Error occurred when resolving plugin metadata", "Package", packageName)
+			return nil, err
+		}
+		if published != plugin.Integrity {
+			mismatches = append(mismatches, PluginIntegrityMismatch{
+				Plugin:    name,
+				Package:   packageName + "@" + version,
+				Expected:  plugin.Integrity,
+				Published: published,
+			})
+		}
+	}
+	logger.Info("Verified plugin integrity", "Plugins", len(pluginNames), "Mismatches", len(mismatches))
+	return mismatches, nil
+}
+
+// getPublishedIntegrity returns the dist.integrity of the package version, or
+// an empty string when the registry does not know the package or the version.
+func getPublishedIntegrity(ctx context.Context, httpClient *http.Client,
+	npmRegistry, authHeader, packageName, version string) (string, error) {
+	packumentURL := strings.TrimSuffix(npmRegistry, "/") + "/" + url.PathEscape(packageName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, packumentURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", operations.NewError(operations.TransientAPI, "registry returned %s for package %s", resp.Status, packageName)
+	}
+
+	packument := &npmPackument{}
+	if err := json.NewDecoder(resp.Body).Decode(packument); err != nil {
+		return "", operations.NewError(operations.TransientAPI, "failed to decode metadata for package %s: %w", packageName, err)
+	}
+	return packument.Versions[version].Dist.Integrity, nil
+}
+
+// splitPackageVersion splits "name@version" into its name and version.
+func splitPackageVersion(pkg string) (string, string) {
+	idx := strings.LastIndex(pkg, "@")
+	if idx <= 0 {
+		return pkg, ""
+	}
+	return pkg[:idx], pkg[idx+1:]
+}
+
+// npmrcAuthHeader returns the Authorization header value that npm would send
+// to the registry based on the _authToken and _auth entries of the .npmrc.
+func npmrcAuthHeader(npmrc, npmRegistry string) string {
+	registryKey := npmrcAuthKey(npmRegistry)
+	if registryKey == "" {
+		return ""
+	}
+
+	authHeader := ""
+	matchedLength := -1
+	scanner := bufio.NewScanner(strings.NewReader(npmrc))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		var prefix, header string
+		switch {
+		case strings.HasSuffix(key, ":_authToken"):
+			prefix, header = strings.TrimSuffix(key, ":_authToken"), "Bearer "+value
+		case strings.HasSuffix(key, ":_auth"):
+			prefix, header = strings.TrimSuffix(key, ":_auth"), "Basic "+value
+		case key == "_authToken":
+			prefix, header = "", "Bearer "+value
+		case key == "_auth":
+			prefix, header = "", "Basic "+value
+		default:
+			continue
+		}
+		// the most specific registry entry wins over global credentials
+		if strings.HasPrefix(registryKey, prefix) && len(prefix) > matchedLength {
+			authHeader, matchedLength = header, len(prefix)
+		}
+	}
+	return authHeader
+}
+
+// GetNpmrc returns the .npmrc content stored in the registry secret.
+func GetNpmrc(ctx context.Context, client client.Client, secretName, secretNamespace string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: secretNamespace, Name: secretName}, secret); err != nil {
+		return "", err
+	}
+	return string(secret.Data[NpmrcSecretKey]), nil
+}
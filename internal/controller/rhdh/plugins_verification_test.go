@@ -0,0 +1,101 @@
+package rhdh
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+)
+
+// newFakeRegistry serves packuments for the given packages and records the
+// Authorization header of the last request.
+func newFakeRegistry(integrities map[string]map[string]string, lastAuth *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		*lastAuth = req.Header.Get("Authorization")
+		versions, ok := integrities[req.URL.Path[1:]]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		packument := map[string]any{"versions": map[string]any{}}
+		for version, integrity := range versions {
+			packument["versions"].(map[string]any)[version] = map[string]any{
+				"dist": map[string]string{"integrity": integrity},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(packument)
+	}))
+}
+
+var _ = Describe("Plugin integrity verification", func() {
+	ctx := context.Background()
+	plugins := map[string]Plugin{
+		Orchestrator: {Package: "backstage-plugin-orchestrator@1.2.0", Integrity: "sha512-good"},
+		Signals:      {Package: "plugin-signals-dynamic@1.2.0", Integrity: "sha512-signals"},
+	}
+
+	var registry *httptest.Server
+	var lastAuth string
+
+	BeforeEach(func() {
+		registry = newFakeRegistry(map[string]map[string]string{
+			"@redhat/backstage-plugin-orchestrator": {"1.2.0": "sha512-good"},
+			"@redhat/plugin-signals-dynamic":        {"1.2.0": "sha512-other"},
+		}, &lastAuth)
+	})
+
+	AfterEach(func() {
+		registry.Close()
+	})
+
+	It("should report plugins whose integrity differs from the registry", func() {
+		mismatches, err := verifyPlugins(ctx, registry.Client(), registry.URL, "@redhat", "", plugins)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mismatches).To(ConsistOf(PluginIntegrityMismatch{
+			Plugin:    Signals,
+			Package:   "@redhat/plugin-signals-dynamic@1.2.0",
+			Expected:  "sha512-signals",
+			Published: "sha512-other",
+		}))
+	})
+
+	It("should report plugins missing from the registry when the scope is wrong", func() {
+		mismatches, err := verifyPlugins(ctx, registry.Client(), registry.URL, "@janus-idp", "", plugins)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mismatches).To(HaveLen(2))
+		Expect(mismatches[0].Published).To(BeEmpty())
+	})
+
+	It("should authenticate with the token of the matching registry in the npmrc", func() {
+		npmrc := "registry=" + registry.URL + "\n" +
+			"//other.registry.example.com/:_authToken=wrong\n" +
+			"//" + registry.Listener.Addr().String() + "/:_authToken=secret\n"
+		_, err := verifyPlugins(ctx, registry.Client(), registry.URL, "@redhat", npmrc, plugins)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastAuth).To(Equal("Bearer secret"))
+	})
+
+	It("should fail when the registry cannot be queried", func() {
+		registry.Close()
+		_, err := verifyPlugins(ctx, registry.Client(), registry.URL, "@redhat", "", plugins)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should only verify the enabled plugins", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{}
+		pluginsSpec := orchestratorv1alpha1.RHDHPlugins{}
+		Expect(enabledPlugins(operator, pluginsSpec)).NotTo(HaveKey(NotificationsEmail))
+
+		pluginsSpec.NotificationsConfig.Enabled = true
+		Expect(enabledPlugins(operator, pluginsSpec)).NotTo(HaveKey(NotificationsEmail))
+
+		operator.SecretRef.NotificationsEmail.Hostname.Key = "NOTIFICATIONS_EMAIL_HOSTNAME"
+		Expect(enabledPlugins(operator, pluginsSpec)).To(HaveKey(NotificationsEmail))
+		Expect(enabledPlugins(operator, pluginsSpec)).To(HaveLen(len(getPlugins())))
+	})
+})
@@ -10,7 +10,7 @@ backend:
     externalAccess:
       - type: static
         options:
-          token: {{ printf "${%s}" .BackendSecret }}
+          token: {{ .BackendSecret.Env }}
           subject: orchestrator
   baseUrl: https://backstage-backstage-{{ .TargetNamespace }}.{{ .ClusterDomain }}
   csp:
@@ -32,19 +32,19 @@ argocd:
   appLocatorMethods:
     - instances:
         - name: main
-          url: {{ printf "${%s}" .ArgoCDUrl }}
-          username: {{ printf "${%s}" .ArgoCDUsername }}
-          password: {{ printf "${%s}" .ArgoCDPassword }}
+          url: {{ .ArgoCDUrl.Env }}
+          username: {{ .ArgoCDUsername.Env }}
+          password: {{ .ArgoCDPassword.Env }}
       type: config
 {{- end }}
 `
 
 type RHDHConfig struct {
 	TargetNamespace string
-	ArgoCDUsername  string
-	ArgoCDPassword  string
-	ArgoCDUrl       string
+	ArgoCDUsername  SecretKeyRef
+	ArgoCDPassword  SecretKeyRef
+	ArgoCDUrl       SecretKeyRef
 	ArgoCDEnabled   bool
-	BackendSecret   string
+	BackendSecret   SecretKeyRef
 	ClusterDomain   string
 }
@@ -1,32 +1,127 @@
 package rhdh
 
-const RHDHAuthTempl = `
-integrations:
-  github:
-    - host: github.com
-      token: {{ printf "${%s}" .GitHubToken }}
+import (
+	"github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+)
 
+const DefaultAuthEnvironment = "development"
+
+const RHDHAuthTempl = RHDHIntegrationsTempl + `
 auth:
   environment: {{ .Environment }}
   providers:
-    {{- if .GitHubClientId }}
-    github:
-      development:
-        clientId: {{ printf "${%s}" .GitHubClientId }}
-        clientSecret: {{ printf "${%s}" .GitHubClientSecret }}
-    {{- end }}
-    {{- if .EnableGuestProvider }}
+    {{- range .Providers }}
+    {{- if eq .Type "guest" }}
     guest:
       dangerouslyAllowOutsideDevelopment: true
       userEntityRef: user:default/guest
+    {{- else }}
+    {{ .Type }}:
+      {{ $.Environment }}:
+        clientId: {{ .ClientID.Env }}
+        clientSecret: {{ .ClientSecret.Env }}
+        {{- if .MetadataUrl.Key }}
+        metadataUrl: {{ .MetadataUrl.Env }}
+        {{- end }}
+        {{- if .TenantID.Key }}
+        tenantId: {{ .TenantID.Env }}
+        {{- end }}
+        {{- if .Audience }}
+        audience: {{ .Audience }}
+        {{- end }}
+        {{- if eq .Type "oidc" }}
+        prompt: auto
+        {{- end }}
+        {{- if .SignInResolver }}
+        signIn:
+          resolvers:
+            - resolver: {{ .SignInResolver }}
+        {{- end }}
     {{- end }}
+    {{- end }}
+{{- if .SignInPage }}
+signInPage: {{ .SignInPage }}
+{{- end }}
 
 `
 
+type RHDHAuthProvider struct {
+	Type           string
+	ClientID       SecretKeyRef
+	ClientSecret   SecretKeyRef
+	MetadataUrl    SecretKeyRef
+	TenantID       SecretKeyRef
+	Audience       string
+	SignInResolver string
+}
+
 type RHDHConfigAuth struct {
-	GitHubToken         string
-	Environment         string
-	GitHubClientId      string
-	GitHubClientSecret  string
-	EnableGuestProvider bool
+	Integrations RHDHIntegrations
+	Environment  string
+	SignInPage   string
+	Providers    []RHDHAuthProvider
+}
+
+// getAuthProviders returns the configured auth providers. When none are
+// configured, the GitHub and guest providers of the secretRef and
+// enableGuestProvider settings are used.
+func getAuthProviders(operator v1alpha1.RHDHOperator) []RHDHAuthProvider {
+	providers := make([]RHDHAuthProvider, 0)
+	if len(operator.Auth.Providers) == 0 {
+		if operator.SecretRef.Github.ClientID.Key != "" {
+			providers = append(providers, RHDHAuthProvider{
+				Type:         string(v1alpha1.GithubAuthProvider),
+				ClientID:     newSecretKeyRef(operator, operator.SecretRef.Github.ClientID),
+				ClientSecret: newSecretKeyRef(operator, operator.SecretRef.Github.ClientSecret),
+			})
+		}
+		if operator.EnableGuestProvider {
+			providers = append(providers, RHDHAuthProvider{Type: string(v1alpha1.GuestAuthProvider)})
+		}
+		return providers
+	}
+
+	for _, provider := range operator.Auth.Providers {
+		providers = append(providers, RHDHAuthProvider{
+			Type:           string(provider.Type),
+			ClientID:       newSecretKeyRef(operator, provider.ClientID),
+			ClientSecret:   newSecretKeyRef(operator, provider.ClientSecret),
+			MetadataUrl:    newSecretKeyRef(operator, provider.MetadataUrl),
+			TenantID:       newSecretKeyRef(operator, provider.TenantID),
+			Audience:       provider.Audience,
+			SignInResolver: provider.SignInResolver,
+		})
+	}
+	return providers
+}
+
+// validateAuthProviders rejects the provider types configured more than once,
+// their configurations would be rendered under the same key.
+func validateAuthProviders(operator v1alpha1.RHDHOperator) error {
+	seen := map[v1alpha1.AuthProviderType]bool{}
+	for _, provider := range operator.Auth.Providers {
+		if seen[provider.Type] {
+			return operations.NewError(operations.InvalidSpec, "auth provider %s is configured more than once", provider.Type)
+		}
+		seen[provider.Type] = true
+	}
+	return nil
+}
+
+// guestProviderEnabled returns whether the guest provider is part of the auth providers.
+func guestProviderEnabled(operator v1alpha1.RHDHOperator) bool {
+	for _, provider := range getAuthProviders(operator) {
+		if provider.Type == string(v1alpha1.GuestAuthProvider) {
+			return true
+		}
+	}
+	return false
+}
+
+func getAuthEnvironment(operator v1alpha1.RHDHOperator) string {
+	if operator.Auth.Environment == "" {
+		return DefaultAuthEnvironment
+	}
+	return operator.Auth.Environment
 }
@@ -0,0 +1,98 @@
+package rhdh
+
+import (
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Auth configuration", func() {
+	secretRef := orchestratorv1alpha1.SecretRefBS{
+		Name:   "backstage-backend-auth-secret",
+		Github: orchestratorv1alpha1.GithubBS{ClientID: orchestratorv1alpha1.SecretKeySelector{Key: "GITHUB_CLIENT_ID"}, ClientSecret: orchestratorv1alpha1.SecretKeySelector{Key: "GITHUB_CLIENT_SECRET"}},
+	}
+
+	It("should fall back to the GitHub and guest providers", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{SecretRef: secretRef, EnableGuestProvider: true}
+		config, err := ConfigMapTemplateFactory(AppConfigRHDHAuthName, "", operator, orchestratorv1alpha1.RHDHPlugins{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(ContainSubstring(`
+auth:
+  environment: development
+  providers:
+    github:
+      development:
+        clientId: ${GITHUB_CLIENT_ID}
+        clientSecret: ${GITHUB_CLIENT_SECRET}
+    guest:
+      dangerouslyAllowOutsideDevelopment: true
+      userEntityRef: user:default/guest
+`))
+	})
+
+	It("should render the configured providers for the auth environment", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{
+			SecretRef: secretRef,
+			Auth: orchestratorv1alpha1.AuthConfig{
+				Environment: "production",
+				SignInPage:  "oidc",
+				Providers: []orchestratorv1alpha1.AuthProvider{
+					{
+						Type:           orchestratorv1alpha1.OIDCAuthProvider,
+						ClientID:       orchestratorv1alpha1.SecretKeySelector{Key: "KEYCLOAK_CLIENT_ID"},
+						ClientSecret:   orchestratorv1alpha1.SecretKeySelector{Key: "KEYCLOAK_CLIENT_SECRET"},
+						MetadataUrl:    orchestratorv1alpha1.SecretKeySelector{Key: "KEYCLOAK_METADATA_URL"},
+						SignInResolver: "emailLocalPartMatchingUserEntityName",
+					},
+					{
+						Type:         orchestratorv1alpha1.GitlabAuthProvider,
+						ClientID:     orchestratorv1alpha1.SecretKeySelector{Key: "GITLAB_CLIENT_ID"},
+						ClientSecret: orchestratorv1alpha1.SecretKeySelector{Key: "GITLAB_CLIENT_SECRET"},
+						Audience:     "https://gitlab.example.com",
+					},
+				},
+			},
+		}
+		config, err := ConfigMapTemplateFactory(AppConfigRHDHAuthName, "", operator, orchestratorv1alpha1.RHDHPlugins{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(ContainSubstring(`
+auth:
+  environment: production
+  providers:
+    oidc:
+      production:
+        clientId: ${KEYCLOAK_CLIENT_ID}
+        clientSecret: ${KEYCLOAK_CLIENT_SECRET}
+        metadataUrl: ${KEYCLOAK_METADATA_URL}
+        prompt: auto
+        signIn:
+          resolvers:
+            - resolver: emailLocalPartMatchingUserEntityName
+    gitlab:
+      production:
+        clientId: ${GITLAB_CLIENT_ID}
+        clientSecret: ${GITLAB_CLIENT_SECRET}
+        audience: https://gitlab.example.com
+signInPage: oidc
+`))
+		Expect(config).NotTo(ContainSubstring("GITHUB_CLIENT_ID"))
+		Expect(BackstageSecretRefs(operator, orchestratorv1alpha1.RHDHPlugins{})).To(ContainElement(
+			SecretKeyRef{SecretName: "backstage-backend-auth-secret", Key: "KEYCLOAK_METADATA_URL"}))
+	})
+	It("should reject a provider type configured twice as an invalid spec", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{
+			SecretRef: secretRef,
+			Auth: orchestratorv1alpha1.AuthConfig{
+				Providers: []orchestratorv1alpha1.AuthProvider{
+					{Type: orchestratorv1alpha1.OIDCAuthProvider, ClientID: orchestratorv1alpha1.SecretKeySelector{Key: "KEYCLOAK_CLIENT_ID"}},
+					{Type: orchestratorv1alpha1.OIDCAuthProvider, ClientID: orchestratorv1alpha1.SecretKeySelector{Key: "OKTA_CLIENT_ID"}},
+				},
+			},
+		}
+		_, err := ConfigMapTemplateFactory(AppConfigRHDHAuthName, "", operator, orchestratorv1alpha1.RHDHPlugins{})
+		Expect(err).To(MatchError(ContainSubstring("auth provider oidc is configured more than once")))
+		Expect(operations.IsTerminal(err)).To(BeTrue())
+	})
+})
@@ -1,35 +1,94 @@
 package rhdh
 
+import (
+	"fmt"
+
+	"github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+)
+
+const (
+	DefaultCatalogRepository = "https://github.com/parodos-dev/workflow-software-templates"
+	DefaultUsersLocation     = "https://github.com/parodos-dev/orchestrator-helm-chart/blob/main/resources/users.yaml"
+)
+
 const RHDHCatalogTempl = `
 catalog:
   rules:
+    {{- range .Rules }}
     - allow:
         [
-          Component,
-          System,
-          Group,
-          Resource,
-          Location,
-          Template,
-          API,
-          User,
-          Domain,
+          {{- range .Allow }}
+          {{ . }},
+          {{- end }}
         ]
+    {{- end }}
   locations:
-    {{- if .EnableGuestProvider }}
-    - type: url
-      target: https://github.com/parodos-dev/orchestrator-helm-chart/blob/main/resources/users.yaml
+    {{- range .Locations }}
+    - type: {{ .Type }}
+      target: {{ .Target }}
+      {{- if .Rules }}
+      rules:
+        {{- range .Rules }}
+        - allow: [{{ range $i, $kind := .Allow }}{{ if $i }}, {{ end }}{{ $kind }}{{ end }}]
+        {{- end }}
+      {{- end }}
     {{- end }}
-    - type: url
-      target: https://github.com/parodos-dev/workflow-software-templates/blob/{{ .CatalogBranch }}/entities/workflow-resources.yaml
-    - type: url
-      target: https://github.com/parodos-dev/workflow-software-templates/blob/{{ .CatalogBranch }}/scaffolder-templates/basic-workflow/template.yaml
-    - type: url
-      target: https://github.com/parodos-dev/workflow-software-templates/blob/{{ .CatalogBranch }}/scaffolder-templates/complex-assessment-workflow/template.yaml
 
 `
 
+// DefaultCatalogRules allows the entity kinds used by the orchestrator.
+var DefaultCatalogRules = []v1alpha1.CatalogRule{
+	{
+		Allow: []string{"Component", "System", "Group", "Resource", "Location", "Template", "API", "User", "Domain"},
+	},
+}
+
 type RHDHConfigCatalog struct {
-	EnableGuestProvider bool
-	CatalogBranch       string
+	Rules     []v1alpha1.CatalogRule
+	Locations []v1alpha1.CatalogLocation
+}
+
+// getCatalogRules returns the configured catalog rules, or the default rules
+// when none are configured.
+func getCatalogRules(operator v1alpha1.RHDHOperator) []v1alpha1.CatalogRule {
+	if len(operator.Catalog.Rules) == 0 {
+		return DefaultCatalogRules
+	}
+	return operator.Catalog.Rules
+}
+
+// getCatalogLocations returns the default workflow locations followed by the
+// configured ones.
+func getCatalogLocations(operator v1alpha1.RHDHOperator) []v1alpha1.CatalogLocation {
+	return append(DefaultCatalogLocations(operator), operator.Catalog.Locations...)
+}
+
+// DefaultCatalogLocations returns the default workflow locations, unless they
+// are excluded: the YAML files of the templates configmap when one is set,
+// and the workflow-software-templates repository otherwise.
+func DefaultCatalogLocations(operator v1alpha1.RHDHOperator) []v1alpha1.CatalogLocation {
+	locations := make([]v1alpha1.CatalogLocation, 0)
+	if !operator.Catalog.ExcludeDefaultLocations {
+		if operator.Catalog.TemplatesConfigMap != "" {
+			locations = append(locations, v1alpha1.CatalogLocation{
+				Type:   v1alpha1.FileCatalogLocation,
+				Target: fmt.Sprintf("%s/*.yaml", CatalogEntitiesMountPath),
+			})
+		} else {
+			if guestProviderEnabled(operator) {
+				locations = append(locations, v1alpha1.CatalogLocation{Type: v1alpha1.URLCatalogLocation, Target: DefaultUsersLocation})
+			}
+			for _, path := range []string{
+				"entities/workflow-resources.yaml",
+				"scaffolder-templates/basic-workflow/template.yaml",
+				"scaffolder-templates/complex-assessment-workflow/template.yaml",
+			} {
+				locations = append(locations, v1alpha1.CatalogLocation{
+					Type:   v1alpha1.URLCatalogLocation,
+					Target: fmt.Sprintf("%s/blob/%s/%s", DefaultCatalogRepository, operator.CatalogBranch, path),
+				})
+			}
+		}
+	}
+	return locations
 }
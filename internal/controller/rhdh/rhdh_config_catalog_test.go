@@ -0,0 +1,106 @@
+package rhdh
+
+import (
+	"context"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Catalog configuration", func() {
+	It("should import the default workflow templates from the catalog branch", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{CatalogBranch: "v1.2.x"}
+		config, err := ConfigMapTemplateFactory(AppConfigRHDHCatalogName, "", operator, orchestratorv1alpha1.RHDHPlugins{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(Equal(`
+catalog:
+  rules:
+    - allow:
+        [
+          Component,
+          System,
+          Group,
+          Resource,
+          Location,
+          Template,
+          API,
+          User,
+          Domain,
+        ]
+  locations:
+    - type: url
+      target: https://github.com/parodos-dev/workflow-software-templates/blob/v1.2.x/entities/workflow-resources.yaml
+    - type: url
+      target: https://github.com/parodos-dev/workflow-software-templates/blob/v1.2.x/scaffolder-templates/basic-workflow/template.yaml
+    - type: url
+      target: https://github.com/parodos-dev/workflow-software-templates/blob/v1.2.x/scaffolder-templates/complex-assessment-workflow/template.yaml
+
+`))
+	})
+
+	It("should render the configured rules and locations", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{
+			Catalog: orchestratorv1alpha1.Catalog{
+				ExcludeDefaultLocations: true,
+				Rules:                   []orchestratorv1alpha1.CatalogRule{{Allow: []string{"Component", "Template"}}},
+				Locations: []orchestratorv1alpha1.CatalogLocation{
+					{
+						Type:   orchestratorv1alpha1.URLCatalogLocation,
+						Target: "https://gitlab.example.com/org/catalog/-/blob/main/all.yaml",
+						Rules:  []orchestratorv1alpha1.CatalogRule{{Allow: []string{"User", "Group"}}},
+					},
+					{Type: orchestratorv1alpha1.FileCatalogLocation, Target: "/opt/app-root/src/my-entities.yaml"},
+				},
+			},
+		}
+		config, err := ConfigMapTemplateFactory(AppConfigRHDHCatalogName, "", operator, orchestratorv1alpha1.RHDHPlugins{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(ContainSubstring(`
+  rules:
+    - allow:
+        [
+          Component,
+          Template,
+        ]
+  locations:
+    - type: url
+      target: https://gitlab.example.com/org/catalog/-/blob/main/all.yaml
+      rules:
+        - allow: [User, Group]
+    - type: file
+      target: /opt/app-root/src/my-entities.yaml
+`))
+	})
+
+	It("should replace the default locations by the templates configmap", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{
+			EnableGuestProvider: true,
+			Catalog:             orchestratorv1alpha1.Catalog{TemplatesConfigMap: "workflow-templates"},
+		}
+		Expect(getCatalogLocations(operator)).To(Equal([]orchestratorv1alpha1.CatalogLocation{
+			{Type: orchestratorv1alpha1.FileCatalogLocation, Target: CatalogEntitiesMountPath + "/*.yaml"},
+		}))
+	})
+
+	It("should wait for the templates configmap", func() {
+		ctx := context.Background()
+		operator := orchestratorv1alpha1.RHDHOperator{
+			Subscription: orchestratorv1alpha1.Subscription{TargetNamespace: "rhdh"},
+			Catalog:      orchestratorv1alpha1.Catalog{TemplatesConfigMap: "workflow-templates"},
+		}
+		k8sClient := fake.NewClientBuilder().Build()
+		err := ValidateTemplatesConfigMap(operator, ctx, k8sClient)
+		Expect(operations.ErrorKindOf(err)).To(Equal(operations.MissingPrerequisite))
+
+		configMap := &corev1.ConfigMap{}
+		configMap.Name = "workflow-templates"
+		configMap.Namespace = "rhdh"
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+		Expect(ValidateTemplatesConfigMap(operator, ctx, k8sClient)).To(Succeed())
+	})
+})
@@ -0,0 +1,208 @@
+package rhdh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+)
+
+const (
+	GithubHost = "github.com"
+	GitlabHost = "gitlab.com"
+	AzureHost  = "dev.azure.com"
+)
+
+const RHDHIntegrationsTempl = `
+{{- with .Integrations }}
+{{- if or .Github .Gitlab .BitbucketCloud .BitbucketServer .Azure }}
+integrations:
+  {{- if .Github }}
+  github:
+    {{- range .Github }}
+    - host: {{ .Host }}
+      {{- if .ApiBaseUrl }}
+      apiBaseUrl: {{ .ApiBaseUrl }}
+      {{- end }}
+      token: {{ .Token.Env }}
+    {{- end }}
+  {{- end }}
+  {{- if .Gitlab }}
+  gitlab:
+    {{- range .Gitlab }}
+    - host: {{ .Host }}
+      {{- if .ApiBaseUrl }}
+      apiBaseUrl: {{ .ApiBaseUrl }}
+      {{- end }}
+      token: {{ .Token.Env }}
+    {{- end }}
+  {{- end }}
+  {{- if .BitbucketCloud }}
+  bitbucketCloud:
+    {{- range .BitbucketCloud }}
+    - username: {{ .Username.Env }}
+      appPassword: {{ .AppPassword.Env }}
+    {{- end }}
+  {{- end }}
+  {{- if .BitbucketServer }}
+  bitbucketServer:
+    {{- range .BitbucketServer }}
+    - host: {{ .Host }}
+      apiBaseUrl: {{ .ApiBaseUrl }}
+      token: {{ .Token.Env }}
+    {{- end }}
+  {{- end }}
+  {{- if .Azure }}
+  azure:
+    {{- range .Azure }}
+    - host: {{ .Host }}
+      credentials:
+        - personalAccessToken: {{ .Token.Env }}
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- end }}
+`
+
+type RHDHIntegration struct {
+	Host        string
+	ApiBaseUrl  string
+	Token       SecretKeyRef
+	Username    SecretKeyRef
+	AppPassword SecretKeyRef
+}
+
+type RHDHIntegrations struct {
+	Github          []RHDHIntegration
+	Gitlab          []RHDHIntegration
+	BitbucketCloud  []RHDHIntegration
+	BitbucketServer []RHDHIntegration
+	Azure           []RHDHIntegration
+}
+
+// getIntegrations returns the SCM integrations with default hosts and API
+// URLs filled in. The github.com integration of secretRef.github.token is
+// kept unless github.com is configured explicitly.
+func getIntegrations(operator v1alpha1.RHDHOperator) RHDHIntegrations {
+	integrations := RHDHIntegrations{}
+	githubComConfigured := false
+	for _, github := range operator.Integrations.Github {
+		host := defaultString(github.Host, GithubHost)
+		apiBaseUrl := github.ApiBaseUrl
+		if apiBaseUrl == "" && host != GithubHost {
+			// GitHub Enterprise Server
+			apiBaseUrl = fmt.Sprintf("https://%s/api/v3", host)
+		}
+		githubComConfigured = githubComConfigured || host == GithubHost
+		integrations.Github = append(integrations.Github, RHDHIntegration{
+			Host:       host,
+			ApiBaseUrl: apiBaseUrl,
+			Token:      newSecretKeyRef(operator, github.Token),
+		})
+	}
+	if operator.SecretRef.Github.Token.Key != "" && !githubComConfigured {
+		integrations.Github = append([]RHDHIntegration{{
+			Host:  GithubHost,
+			Token: newSecretKeyRef(operator, operator.SecretRef.Github.Token),
+		}}, integrations.Github...)
+	}
+	for _, gitlab := range operator.Integrations.Gitlab {
+		host := defaultString(gitlab.Host, GitlabHost)
+		integrations.Gitlab = append(integrations.Gitlab, RHDHIntegration{
+			Host:       host,
+			ApiBaseUrl: defaultString(gitlab.ApiBaseUrl, fmt.Sprintf("https://%s/api/v4", host)),
+			Token:      newSecretKeyRef(operator, gitlab.Token),
+		})
+	}
+	for _, bitbucket := range operator.Integrations.BitbucketCloud {
+		integrations.BitbucketCloud = append(integrations.BitbucketCloud, RHDHIntegration{
+			Username:    newSecretKeyRef(operator, bitbucket.Username),
+			AppPassword: newSecretKeyRef(operator, bitbucket.AppPassword),
+		})
+	}
+	for _, bitbucket := range operator.Integrations.BitbucketServer {
+		integrations.BitbucketServer = append(integrations.BitbucketServer, RHDHIntegration{
+			Host:       bitbucket.Host,
+			ApiBaseUrl: defaultString(bitbucket.ApiBaseUrl, fmt.Sprintf("https://%s/rest/api/1.0", bitbucket.Host)),
+			Token:      newSecretKeyRef(operator, bitbucket.Token),
+		})
+	}
+	for _, azure := range operator.Integrations.Azure {
+		integrations.Azure = append(integrations.Azure, RHDHIntegration{
+			Host:  defaultString(azure.Host, AzureHost),
+			Token: newSecretKeyRef(operator, azure.Token),
+		})
+	}
+	return integrations
+}
+
+// validateIntegrations rejects the integrations that would render an invalid
+// URL or credential: hosts with a scheme or path, Bitbucket Server without a
+// host and missing credential keys.
+func validateIntegrations(operator v1alpha1.RHDHOperator) error {
+	gitIntegrations := map[string][]v1alpha1.GitIntegration{
+		"github":          operator.Integrations.Github,
+		"gitlab":          operator.Integrations.Gitlab,
+		"bitbucketServer": operator.Integrations.BitbucketServer,
+		"azure":           operator.Integrations.Azure,
+	}
+	for _, scm := range []string{"github", "gitlab", "bitbucketServer", "azure"} {
+		for i, integration := range gitIntegrations[scm] {
+			switch {
+			case integration.Host == "" && scm == "bitbucketServer":
+				return operations.NewError(operations.InvalidSpec, "integration %s[%d] has no host", scm, i)
+			case strings.ContainsAny(integration.Host, "/?#"):
+				return operations.NewError(operations.InvalidSpec, "integration %s[%d] host %q must be a host name without a scheme or path", scm, i, integration.Host)
+			case integration.Token.Key == "":
+				return operations.NewError(operations.InvalidSpec, "integration %s[%d] has no token key", scm, i)
+			}
+		}
+	}
+	for i, bitbucket := range operator.Integrations.BitbucketCloud {
+		if bitbucket.Username.Key == "" || bitbucket.AppPassword.Key == "" {
+			return operations.NewError(operations.InvalidSpec, "integration bitbucketCloud[%d] needs both the username and appPassword keys", i)
+		}
+	}
+	return nil
+}
+
+// secretRefs returns the credentials referenced by the integrations.
+func (i RHDHIntegrations) secretRefs() []SecretKeyRef {
+	refs := make([]SecretKeyRef, 0)
+	for _, integrations := range [][]RHDHIntegration{i.Github, i.Gitlab, i.BitbucketCloud, i.BitbucketServer, i.Azure} {
+		for _, integration := range integrations {
+			refs = append(refs, integration.Token, integration.Username, integration.AppPassword)
+		}
+	}
+	return refs
+}
+
+// scaffolderModules returns the scaffolder backend modules providing the
+// publish actions for the configured integrations.
+func (i RHDHIntegrations) scaffolderModules() []string {
+	modules := make([]string, 0)
+	if len(i.Github) > 0 {
+		modules = append(modules, "backstage-plugin-scaffolder-backend-module-github-dynamic")
+	}
+	if len(i.Gitlab) > 0 {
+		modules = append(modules, "backstage-plugin-scaffolder-backend-module-gitlab-dynamic")
+	}
+	if len(i.BitbucketCloud) > 0 {
+		modules = append(modules, "backstage-plugin-scaffolder-backend-module-bitbucket-cloud-dynamic")
+	}
+	if len(i.BitbucketServer) > 0 {
+		modules = append(modules, "backstage-plugin-scaffolder-backend-module-bitbucket-server-dynamic")
+	}
+	if len(i.Azure) > 0 {
+		modules = append(modules, "backstage-plugin-scaffolder-backend-module-azure-dynamic")
+	}
+	return modules
+}
+
+func defaultString(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,114 @@
+package rhdh
+
+import (
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SCM integrations", func() {
+	secretRef := orchestratorv1alpha1.SecretRefBS{
+		Name:   "backstage-backend-auth-secret",
+		Github: orchestratorv1alpha1.GithubBS{Token: orchestratorv1alpha1.SecretKeySelector{Key: "GITHUB_TOKEN"}},
+	}
+
+	It("should keep the github.com integration of the GitHub token", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{SecretRef: secretRef}
+		config, err := ConfigMapTemplateFactory(AppConfigRHDHAuthName, "", operator, orchestratorv1alpha1.RHDHPlugins{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(ContainSubstring(`
+integrations:
+  github:
+    - host: github.com
+      token: ${GITHUB_TOKEN}
+
+auth:`))
+	})
+
+	It("should default the hosts and API URLs of the integrations", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{
+			SecretRef: secretRef,
+			Integrations: orchestratorv1alpha1.Integrations{
+				Github:          []orchestratorv1alpha1.GitIntegration{{Host: "ghe.example.com", Token: orchestratorv1alpha1.SecretKeySelector{Key: "GHE_TOKEN"}}},
+				Gitlab:          []orchestratorv1alpha1.GitIntegration{{Token: orchestratorv1alpha1.SecretKeySelector{Key: "GITLAB_TOKEN"}}, {Host: "gitlab.example.com", Token: orchestratorv1alpha1.SecretKeySelector{Key: "GITLAB_SELF_MANAGED_TOKEN"}}},
+				BitbucketCloud:  []orchestratorv1alpha1.BitbucketCloudIntegration{{Username: orchestratorv1alpha1.SecretKeySelector{Key: "BITBUCKET_USERNAME"}, AppPassword: orchestratorv1alpha1.SecretKeySelector{Key: "BITBUCKET_APP_PASSWORD"}}},
+				BitbucketServer: []orchestratorv1alpha1.GitIntegration{{Host: "bitbucket.example.com", Token: orchestratorv1alpha1.SecretKeySelector{Key: "BITBUCKET_SERVER_TOKEN"}}},
+				Azure:           []orchestratorv1alpha1.GitIntegration{{Token: orchestratorv1alpha1.SecretKeySelector{Key: "AZURE_TOKEN"}}},
+			},
+		}
+		config, err := ConfigMapTemplateFactory(AppConfigRHDHAuthName, "", operator, orchestratorv1alpha1.RHDHPlugins{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(ContainSubstring(`
+integrations:
+  github:
+    - host: github.com
+      token: ${GITHUB_TOKEN}
+    - host: ghe.example.com
+      apiBaseUrl: https://ghe.example.com/api/v3
+      token: ${GHE_TOKEN}
+  gitlab:
+    - host: gitlab.com
+      apiBaseUrl: https://gitlab.com/api/v4
+      token: ${GITLAB_TOKEN}
+    - host: gitlab.example.com
+      apiBaseUrl: https://gitlab.example.com/api/v4
+      token: ${GITLAB_SELF_MANAGED_TOKEN}
+  bitbucketCloud:
+    - username: ${BITBUCKET_USERNAME}
+      appPassword: ${BITBUCKET_APP_PASSWORD}
+  bitbucketServer:
+    - host: bitbucket.example.com
+      apiBaseUrl: https://bitbucket.example.com/rest/api/1.0
+      token: ${BITBUCKET_SERVER_TOKEN}
+  azure:
+    - host: dev.azure.com
+      credentials:
+        - personalAccessToken: ${AZURE_TOKEN}
+
+auth:`))
+
+		Expect(BackstageSecretRefs(operator, orchestratorv1alpha1.RHDHPlugins{})).To(ContainElements(
+			SecretKeyRef{SecretName: "backstage-backend-auth-secret", Key: "GHE_TOKEN"},
+			SecretKeyRef{SecretName: "backstage-backend-auth-secret", Key: "GITLAB_SELF_MANAGED_TOKEN"},
+			SecretKeyRef{SecretName: "backstage-backend-auth-secret", Key: "BITBUCKET_APP_PASSWORD"},
+			SecretKeyRef{SecretName: "backstage-backend-auth-secret", Key: "AZURE_TOKEN"},
+		))
+
+		plugins, err := ConfigMapTemplateFactory(AppConfigRHDHDynamicPluginName, "", operator, orchestratorv1alpha1.RHDHPlugins{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plugins).To(ContainSubstring("./dynamic-plugins/dist/backstage-plugin-scaffolder-backend-module-gitlab-dynamic"))
+		Expect(plugins).To(ContainSubstring("./dynamic-plugins/dist/backstage-plugin-scaffolder-backend-module-azure-dynamic"))
+	})
+
+	It("should not duplicate an explicitly configured github.com integration", func() {
+		operator := orchestratorv1alpha1.RHDHOperator{
+			SecretRef: secretRef,
+			Integrations: orchestratorv1alpha1.Integrations{
+				Github: []orchestratorv1alpha1.GitIntegration{{Host: "github.com", Token: orchestratorv1alpha1.SecretKeySelector{Key: "GITHUB_ORG_TOKEN"}}},
+			},
+		}
+		integrations := getIntegrations(operator)
+		Expect(integrations.Github).To(HaveLen(1))
+		Expect(integrations.Github[0].Token.Key).To(Equal("GITHUB_ORG_TOKEN"))
+		Expect(integrations.Github[0].ApiBaseUrl).To(BeEmpty())
+	})
+	It("should reject the integrations rendering an invalid URL or credential", func() {
+		invalid := map[string]orchestratorv1alpha1.Integrations{
+			"has no host": {BitbucketServer: []orchestratorv1alpha1.GitIntegration{
+				{Token: orchestratorv1alpha1.SecretKeySelector{Key: "BITBUCKET_SERVER_TOKEN"}}}},
+			"must be a host name": {Github: []orchestratorv1alpha1.GitIntegration{
+				{Host: "https://ghe.example.com", Token: orchestratorv1alpha1.SecretKeySelector{Key: "GHE_TOKEN"}}}},
+			"has no token key": {Gitlab: []orchestratorv1alpha1.GitIntegration{{Host: "gitlab.example.com"}}},
+			"needs both the username and appPassword keys": {BitbucketCloud: []orchestratorv1alpha1.BitbucketCloudIntegration{
+				{Username: orchestratorv1alpha1.SecretKeySelector{Key: "BITBUCKET_USERNAME"}}}},
+		}
+		for message, integrations := range invalid {
+			operator := orchestratorv1alpha1.RHDHOperator{SecretRef: secretRef, Integrations: integrations}
+			_, err := ConfigMapTemplateFactory(AppConfigRHDHAuthName, "", operator, orchestratorv1alpha1.RHDHPlugins{})
+			Expect(err).To(MatchError(ContainSubstring(message)))
+			Expect(operations.IsTerminal(err)).To(BeTrue())
+		}
+	})
+})
@@ -1,13 +1,13 @@
 package rhdh
 
 type RHDHDynamicPluginConfig struct {
-	K8ClusterToken               string
-	K8ClusterUrl                 string
+	K8ClusterToken               SecretKeyRef
+	K8ClusterUrl                 SecretKeyRef
 	TektonEnabled                bool
 	ArgoCDEnabled                bool
-	ArgoCDUrl                    string
-	ArgoCDUsername               string
-	ArgoCDPassword               string
+	ArgoCDUrl                    SecretKeyRef
+	ArgoCDUsername               SecretKeyRef
+	ArgoCDPassword               SecretKeyRef
 	OrchestratorBackendPackage   string
 	OrchestratorBackendIntegrity string
 	OrchestratorPackage          string
@@ -24,13 +24,14 @@ type RHDHDynamicPluginConfig struct {
 	NotificationEmailPackage     string
 	NotificationEmailIntegrity   string
 	NotificationEmailEnabled     bool
-	NotificationEmailHostname    string
-	NotificationEmailUsername    string
-	NotificationEmailPassword    string
+	NotificationEmailHostname    SecretKeyRef
+	NotificationEmailUsername    SecretKeyRef
+	NotificationEmailPassword    SecretKeyRef
 	NotificationEmailSender      string
 	NotificationEmailReplyTo     string
 	NotificationEmailPort        int
 	WorkflowNamespace            string
+	ScaffolderModules            []string
 }
 
 const RHDHDynamicPluginTempl = `
@@ -38,7 +39,7 @@ includes:
   - dynamic-plugins.default.yaml
 
 plugins:
-  {{- if and (.K8ClusterToken) (.K8ClusterUrl) }}
+  {{- if and (.K8ClusterToken.Key) (.K8ClusterUrl.Key) }}
   - package: ./dynamic-plugins/dist/backstage-plugin-kubernetes-backend-dynamic
     disabled: false
     pluginConfig:
@@ -62,10 +63,10 @@ plugins:
           - type: 'config'
             clusters:
               - name: 'Default Cluster'
-                url: {{ printf "${%s}" .K8ClusterUrl }}
+                url: {{ .K8ClusterUrl.Env }}
                 authProvider: 'serviceAccount'
                 skipTLSVerify: true
-                serviceAccountToken: {{ printf "${%s}" .K8ClusterToken }}
+                serviceAccountToken: {{ .K8ClusterToken.Env }}
   - package: ./dynamic-plugins/dist/backstage-plugin-kubernetes
     disabled: false
   {{- if .TektonEnabled }}
@@ -74,7 +75,7 @@ plugins:
   {{- end }}
   {{- end }}
   
-  {{- if and (.ArgoCDEnabled) (.ArgoCDUrl) (.ArgoCDUsername) }}
+  {{- if and (.ArgoCDEnabled) (.ArgoCDUrl.Key) (.ArgoCDUsername.Key) }}
   - package: ./dynamic-plugins/dist/janus-idp-backstage-plugin-argocd
     disabled: false
   - package: ./dynamic-plugins/dist/roadiehq-backstage-plugin-argo-cd-backend-dynamic
@@ -83,6 +84,11 @@ plugins:
     disabled: false
   {{- end }}
   
+  {{- range .ScaffolderModules }}
+  - package: ./dynamic-plugins/dist/{{ . }}
+    disabled: false
+  {{- end }}
+
   - package: "{{ .Scope }}/{{ .OrchestratorBackendPackage }}"
     disabled: false
     integrity: {{ .OrchestratorBackendIntegrity }}
@@ -143,7 +149,7 @@ plugins:
     disabled: false
     integrity: {{ .SignalsBackendIntegrity }}
 
-  {{- if and (.NotificationEmailEnabled) (.NotificationEmailHostname) }}
+  {{- if and (.NotificationEmailEnabled) (.NotificationEmailHostname.Key) }}
   - package: "{{ .Scope }}/{{ .NotificationEmailPackage }}"
     disabled: false
     integrity: {{ .NotificationEmailIntegrity}}
@@ -153,14 +159,14 @@ plugins:
           email:
             transportConfig:
               transport: smtp
-              hostname: {{ printf "${%s}" .NotificationEmailHostname }}
+              hostname: {{ .NotificationEmailHostname.Env }}
               port: {{ .NotificationEmailPort }}
               secure: false
-              {{- if .NotificationEmailUsername }}
-              username: {{ printf "${%s}" .NotificationEmailUsername }}
+              {{- if .NotificationEmailUsername.Key }}
+              username: {{ .NotificationEmailUsername.Env }}
               {{- end}}
-              {{- if .NotificationEmailPassword }}
-              password: {{ .NotificationEmailPassword }}
+              {{- if .NotificationEmailPassword.Key }}
+              password: {{ .NotificationEmailPassword.Env }}
               {{- end}}
               sender: {{ .NotificationEmailSender }}
               {{- if .NotificationEmailReplyTo }}
@@ -0,0 +1,134 @@
+package rhdh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	rhdh "redhat-developer/red-hat-developer-hub-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// SecretKeyRef references a credential stored under Key in the secret
+// SecretName. The key is injected into Backstage as an environment variable
+// of the same name, which the app-config refers to through Env.
+type SecretKeyRef struct {
+	SecretName string
+	Key        string
+}
+
+// Env returns the app-config placeholder resolved by Backstage from the
+// environment variable of the referenced key.
+func (r SecretKeyRef) Env() string {
+	return fmt.Sprintf("${%s}", r.Key)
+}
+
+// newSecretKeyRef resolves the selector against the secretRef name, which is
+// the default secret of the credentials.
+func newSecretKeyRef(operator orchestratorv1alpha1.RHDHOperator, selector orchestratorv1alpha1.SecretKeySelector) SecretKeyRef {
+	return SecretKeyRef{SecretName: defaultString(selector.Name, operator.SecretRef.Name), Key: selector.Key}
+}
+
+// BackstageSecretRefs returns the credentials referenced by the Backstage
+// configuration. Credentials of disabled integrations are left out.
+func BackstageSecretRefs(operator orchestratorv1alpha1.RHDHOperator, plugins orchestratorv1alpha1.RHDHPlugins) []SecretKeyRef {
+	secretRef := operator.SecretRef
+	selectors := []orchestratorv1alpha1.SecretKeySelector{
+		secretRef.Backstage.BackendSecret,
+		secretRef.Github.Token,
+		secretRef.ClusterTokenUrl.ClusterToken,
+		secretRef.ClusterTokenUrl.ClusterUrl,
+	}
+	if secretRef.ArgoCD.Enabled {
+		selectors = append(selectors, secretRef.ArgoCD.Url, secretRef.ArgoCD.Username, secretRef.ArgoCD.Password)
+	}
+	if plugins.NotificationsConfig.Enabled {
+		selectors = append(selectors,
+			secretRef.NotificationsEmail.Hostname,
+			secretRef.NotificationsEmail.Username,
+			secretRef.NotificationsEmail.Password)
+	}
+	candidates := make([]SecretKeyRef, 0, len(selectors))
+	for _, selector := range selectors {
+		candidates = append(candidates, newSecretKeyRef(operator, selector))
+	}
+	candidates = append(candidates, getIntegrations(operator).secretRefs()...)
+	for _, provider := range getAuthProviders(operator) {
+		candidates = append(candidates, provider.ClientID, provider.ClientSecret, provider.MetadataUrl, provider.TenantID)
+	}
+
+	refs := make([]SecretKeyRef, 0, len(candidates))
+	seen := map[SecretKeyRef]bool{}
+	for _, ref := range candidates {
+		if ref.Key == "" || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// ValidateSecretRefs checks that every referenced key exists in its secret.
+func ValidateSecretRefs(ctx context.Context, client client.Client, namespace string, refs []SecretKeyRef) error {
+	logger := log.FromContext(ctx)
+
+	envs := map[string]string{}
+	for _, ref := range refs {
+		if ref.SecretName == "" {
+			return operations.NewError(operations.InvalidSpec, "key %s is referenced but no backstage secret name is set", ref.Key)
+		}
+		// the key names the environment variable, it can only come from one secret
+		if secretName, found := envs[ref.Key]; found && secretName != ref.SecretName {
+			return operations.NewError(operations.InvalidSpec, "key %s is referenced from both secrets %s and %s", ref.Key, secretName, ref.SecretName)
+		}
+		envs[ref.Key] = ref.SecretName
+	}
+
+	secrets := map[string]*corev1.Secret{}
+	missing := map[string][]string{}
+	for _, ref := range refs {
+		secret, ok := secrets[ref.SecretName]
+		if !ok {
+			secret = &corev1.Secret{}
+			if err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.SecretName}, secret); err != nil {
+				logger.Error(err, "Error occurred when retrieving secret", "Secret", ref.SecretName, "Namespace", namespace)
+				return err
+			}
+			secrets[ref.SecretName] = secret
+		}
+		if _, found := secret.Data[ref.Key]; !found {
+			missing[ref.SecretName] = append(missing[ref.SecretName], ref.Key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	secretNames := make([]string, 0, len(missing))
+	for name := range missing {
+		secretNames = append(secretNames, name)
+	}
+	sort.Strings(secretNames)
+	details := make([]string, 0, len(secretNames))
+	for _, name := range secretNames {
+		details = append(details, fmt.Sprintf("secret %s/%s is missing keys %s", namespace, name, strings.Join(missing[name], ", ")))
+	}
+	return operations.NewError(operations.MissingPrerequisite, "invalid secret references: %s", strings.Join(details, "; "))
+}
+
+// secretRefsToEnvs converts the references into the per-key secrets injected
+// as environment variables into Backstage.
+func secretRefsToEnvs(refs []SecretKeyRef) []rhdh.ObjectKeyRef {
+	envs := make([]rhdh.ObjectKeyRef, 0, len(refs))
+	for _, ref := range refs {
+		envs = append(envs, rhdh.ObjectKeyRef{Name: ref.SecretName, Key: ref.Key})
+	}
+	return envs
+}
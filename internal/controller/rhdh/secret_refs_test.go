@@ -0,0 +1,88 @@
+package rhdh
+
+import (
+	"context"
+
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	operations "github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backstage secret references", func() {
+	ctx := context.Background()
+	namespace := "rhdh-operator"
+
+	operator := orchestratorv1alpha1.RHDHOperator{
+		SecretRef: orchestratorv1alpha1.SecretRefBS{
+			Name:      "backstage-backend-auth-secret",
+			Backstage: orchestratorv1alpha1.BackstageSecret{BackendSecret: orchestratorv1alpha1.SecretKeySelector{Key: "BACKEND_SECRET"}},
+			NotificationsEmail: orchestratorv1alpha1.NotificationEmailBS{
+				Hostname: orchestratorv1alpha1.SecretKeySelector{Key: "NOTIFICATIONS_EMAIL_HOSTNAME"},
+				Password: orchestratorv1alpha1.SecretKeySelector{Key: "NOTIFICATIONS_EMAIL_PASSWORD"},
+			},
+		},
+	}
+	plugins := orchestratorv1alpha1.RHDHPlugins{
+		NotificationsConfig: orchestratorv1alpha1.NotificationConfig{Enabled: true, Port: 587},
+	}
+
+	It("should only reference the keys of enabled integrations", func() {
+		disabled := plugins
+		disabled.NotificationsConfig.Enabled = false
+		Expect(BackstageSecretRefs(operator, disabled)).To(ConsistOf(
+			SecretKeyRef{SecretName: "backstage-backend-auth-secret", Key: "BACKEND_SECRET"},
+		))
+		Expect(BackstageSecretRefs(operator, plugins)).To(HaveLen(3))
+	})
+
+	It("should report the keys missing from the backstage secret", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "backstage-backend-auth-secret", Namespace: namespace},
+			Data:       map[string][]byte{"BACKEND_SECRET": []byte("secret")},
+		}
+		k8sClient := fake.NewClientBuilder().WithObjects(secret).Build()
+
+		err := ValidateSecretRefs(ctx, k8sClient, namespace, BackstageSecretRefs(operator, plugins))
+		Expect(err).To(MatchError(ContainSubstring("NOTIFICATIONS_EMAIL_HOSTNAME, NOTIFICATIONS_EMAIL_PASSWORD")))
+		Expect(operations.ErrorKindOf(err)).To(Equal(operations.MissingPrerequisite))
+
+		secret.Data["NOTIFICATIONS_EMAIL_HOSTNAME"] = []byte("smtp.example.com")
+		secret.Data["NOTIFICATIONS_EMAIL_PASSWORD"] = []byte("password")
+		Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+		Expect(ValidateSecretRefs(ctx, k8sClient, namespace, BackstageSecretRefs(operator, plugins))).To(Succeed())
+	})
+
+	It("should report keys referenced without a secret name as an invalid spec", func() {
+		unnamed := operator
+		unnamed.SecretRef.Name = ""
+		k8sClient := fake.NewClientBuilder().Build()
+
+		err := ValidateSecretRefs(ctx, k8sClient, namespace, BackstageSecretRefs(unnamed, plugins))
+		Expect(err).To(HaveOccurred())
+		Expect(operations.IsTerminal(err)).To(BeTrue())
+	})
+
+	It("should render the email password through the environment", func() {
+		config, err := ConfigMapTemplateFactory(AppConfigRHDHDynamicPluginName, "example.com", operator, plugins)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(ContainSubstring("hostname: ${NOTIFICATIONS_EMAIL_HOSTNAME}"))
+		Expect(config).To(ContainSubstring("password: ${NOTIFICATIONS_EMAIL_PASSWORD}"))
+		Expect(config).NotTo(ContainSubstring("username:"))
+	})
+	It("should reject a key referenced from two secrets as an invalid spec", func() {
+		conflicting := operator
+		conflicting.Integrations.Gitlab = []orchestratorv1alpha1.GitIntegration{
+			{Token: orchestratorv1alpha1.SecretKeySelector{Name: "gitlab-credentials", Key: "BACKEND_SECRET"}},
+		}
+		k8sClient := fake.NewClientBuilder().Build()
+
+		err := ValidateSecretRefs(ctx, k8sClient, namespace, BackstageSecretRefs(conflicting, plugins))
+		Expect(err).To(MatchError(ContainSubstring("key BACKEND_SECRET is referenced from both secrets")))
+		Expect(operations.IsTerminal(err)).To(BeTrue())
+	})
+})
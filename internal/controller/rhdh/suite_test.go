@@ -0,0 +1,14 @@
+package rhdh
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRHDH(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "RHDH Suite")
+}
@@ -20,14 +20,17 @@ import (
 	olmclientset "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned"
 	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
 	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
 	"github.com/parodos-dev/orchestrator-operator/internal/controller/util"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sort"
 )
 
 const (
@@ -41,19 +44,19 @@ const (
 	SonataFlowSubscriptionName       = "logic-operator-rhel8"
 )
 
-func getSonataFlowPersistence(orchestrator *orchestratorv1alpha1.Orchestrator) *sonataapi.PersistenceOptionsSpec {
+func getSonataFlowPersistence(postgres orchestratorv1alpha1.Postgres) *sonataapi.PersistenceOptionsSpec {
 	return &sonataapi.PersistenceOptionsSpec{
 		PostgreSQL: &sonataapi.PersistencePostgreSQL{
 			SecretRef: sonataapi.PostgreSQLSecretOptions{
-				Name:        orchestrator.Spec.PostgresDB.AuthSecret.SecretName,
-				UserKey:     orchestrator.Spec.PostgresDB.AuthSecret.UserKey,
-				PasswordKey: orchestrator.Spec.PostgresDB.AuthSecret.PasswordKey,
+				Name:        postgres.AuthSecret.SecretName,
+				UserKey:     postgres.AuthSecret.UserKey,
+				PasswordKey: postgres.AuthSecret.PasswordKey,
 			},
 			ServiceRef: &sonataapi.PostgreSQLServiceOptions{
 				SQLServiceOptions: &sonataapi.SQLServiceOptions{
-					Name:         orchestrator.Spec.PostgresDB.ServiceName,
-					Namespace:    orchestrator.Spec.PostgresDB.ServiceNameSpace,
-					DatabaseName: orchestrator.Spec.PostgresDB.DatabaseName,
+					Name:         postgres.ServiceName,
+					Namespace:    postgres.ServiceNameSpace,
+					DatabaseName: postgres.DatabaseName,
 				},
 			},
 		},
@@ -69,10 +72,18 @@ func handleSonataFlowClusterCR(ctx context.Context, client client.Client, crName
 	if err == nil {
 		// CR exists; check for CR updates
 		logger.Info("CR resource  found.", "CR-Name", crName, "Namespace", SonataFlowNamespace)
-		sfcCR.Spec = getSonataFlowClusterSpec()
+		// only the platform reference is owned by the orchestrator
+		desired := getSonataFlowClusterSpec()
+		if equality.Semantic.DeepEqual(sfcCR.Spec.PlatformRef, desired.PlatformRef) {
+			return nil
+		}
+		sfcCR.Spec.PlatformRef = desired.PlatformRef
 		if err = client.Update(ctx, sfcCR); err != nil {
 			logger.Error(err, "Failed to update CR", "CR-Name", sfcCR.Name)
+			kube.EventsFromContext(ctx).Warning(kube.ReasonResourceUpdateFailed, "Failed to update %s %s: %v", SonataFlowClusterPlatformKind, sfcCR.Name, err)
+			return err
 		}
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceUpdated, "Updated %s %s", SonataFlowClusterPlatformKind, sfcCR.Name)
 		return nil
 	} else {
 		if apierrors.IsNotFound(err) {
@@ -93,9 +104,11 @@ func handleSonataFlowClusterCR(ctx context.Context, client client.Client, crName
 			// Create sonataflow cluster CR
 			if err := client.Create(ctx, sonataFlowClusterCR); err != nil {
 				logger.Error(err, "Error occurred when creating Custom Resource", "CR-Name", crName)
+				kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create %s %s: %v", SonataFlowClusterPlatformKind, crName, err)
 				return err
 			}
 			logger.Info("Successfully created SonataFlowClusterPlatform resource %s", sonataFlowClusterCR.Name)
+			kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created %s %s", SonataFlowClusterPlatformKind, sonataFlowClusterCR.Name)
 			return nil
 		}
 		logger.Error(err, "Error occurred when retrieving SonataFlowClusterPlatform CR", "CR-Name", crName)
@@ -115,12 +128,13 @@ func getSonataFlowClusterSpec() sonataapi.SonataFlowClusterPlatformSpec {
 func handleSonataFlowPlatformCR(
 	ctx context.Context,
 	client client.Client,
-	orchestrator *orchestratorv1alpha1.Orchestrator,
+	spec orchestratorv1alpha1.OrchestratorSpec,
 	crName string) error {
 	logger := log.FromContext(ctx)
 
 	logger.Info("Starting CR creation for SonataFlowPlatform...")
 
+	desired := getSonataFlowPlatformSpec(spec)
 	sfpCR := &sonataapi.SonataFlowPlatform{}
 	err := client.Get(ctx, types.NamespacedName{
 		Namespace: SonataFlowNamespace,
@@ -130,8 +144,21 @@ func handleSonataFlowPlatformCR(
 	if err == nil {
 		// CR exists; check for CR updates
 		logger.Info("CR resource  found.", "CR-Name", crName, "Namespace", SonataFlowNamespace)
-		err = client.Update(ctx, sfpCR)
-
+		updated := sfpCR.Spec
+		applySonataFlowPlatformSpec(&updated, desired)
+		if equality.Semantic.DeepEqual(sfpCR.Spec, updated) {
+			return nil
+		}
+		sfpCR.Spec = updated
+		if err := client.Update(ctx, sfpCR); err != nil {
+			logger.Error(err, "Failed to update CR", "CR-Name", sfpCR.Name)
+			kube.EventsFromContext(ctx).Warning(kube.ReasonResourceUpdateFailed, "Failed to update %s %s/%s: %v",
+				SonataFlowPlatformKind, SonataFlowNamespace, sfpCR.Name, err)
+			return err
+		}
+		metrics.RecordDriftCorrection(ctx, metrics.ComponentSonataFlow, SonataFlowPlatformKind)
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceUpdated, "Updated %s %s/%s",
+			SonataFlowPlatformKind, SonataFlowNamespace, sfpCR.Name)
 		return nil
 	} else {
 		if apierrors.IsNotFound(err) {
@@ -148,15 +175,19 @@ func handleSonataFlowPlatformCR(
 					Namespace: SonataFlowNamespace,
 					Labels:    kube.AddLabel(),
 				},
-				Spec: getSonataFlowPlatformSpec(orchestrator),
+				Spec: desired,
 			}
-			logger.Info("Persistence function", "Persistent", getSonataFlowPersistence(orchestrator))
+			logger.Info("Persistence function", "Persistent", getSonataFlowPersistence(spec.PostgresDB))
 			// Create sonataflowplatform CR
 			if err := client.Create(ctx, sonataFlowPlatformCR); err != nil {
 				logger.Error(err, "Failed to create Custom Resource", "CR-Name", crName)
+				kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create %s %s/%s: %v",
+					SonataFlowPlatformKind, SonataFlowNamespace, SonataFlowPlatformCRName, err)
 				return err
 			}
 			logger.Info("Successfully created CR", "CR-Name", sonataFlowPlatformCR.Name)
+			kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created %s %s/%s",
+				SonataFlowPlatformKind, SonataFlowNamespace, SonataFlowPlatformCRName)
 			return nil
 		}
 		logger.Error(err, "Error occurred when retrieving SonataFlowPlatform CR", "CR-Name", crName)
@@ -164,44 +195,174 @@ func handleSonataFlowPlatformCR(
 	return err
 }
 
-func getSonataFlowPlatformSpec(orchestrator *orchestratorv1alpha1.Orchestrator) sonataapi.SonataFlowPlatformSpec {
-	limitResourceMap := make(map[corev1.ResourceName]resource.Quantity)
-
-	cpuQuantity, _ := resource.ParseQuantity(orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform.Resources.Limits.Cpu)
-	memoryQuantity, _ := resource.ParseQuantity(orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform.Resources.Limits.Memory)
-	limitResourceMap[corev1.ResourceCPU] = cpuQuantity
-	limitResourceMap[corev1.ResourceMemory] = memoryQuantity
-	//logger.Info("Limit Map", "Map", limitResourceMap)
-
-	requestResourceMap := make(map[corev1.ResourceName]resource.Quantity)
-	requestCpuQuantity, _ := resource.ParseQuantity(orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform.Resources.Requests.Cpu)
-	requestMemoryQuantity, _ := resource.ParseQuantity(orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform.Resources.Requests.Memory)
-	requestResourceMap[corev1.ResourceCPU] = requestCpuQuantity
-	requestResourceMap[corev1.ResourceMemory] = requestMemoryQuantity
-	//logger.Info("Request Map", "Map", requestResourceMap)
-
-	return sonataapi.SonataFlowPlatformSpec{
-		Build: sonataapi.BuildPlatformSpec{
-			Template: sonataapi.BuildTemplate{
-				Resources: corev1.ResourceRequirements{
-					Limits:   limitResourceMap,
-					Requests: requestResourceMap,
-				},
-			}},
-		Services: &sonataapi.ServicesPlatformSpec{
-			DataIndex: &sonataapi.ServiceSpec{
-				Enabled:     util.MakePointer(true),
-				Persistence: getSonataFlowPersistence(orchestrator),
-			},
-			JobService: &sonataapi.ServiceSpec{
-				Enabled:     util.MakePointer(true),
-				Persistence: getSonataFlowPersistence(orchestrator),
+// applySonataFlowPlatformSpec sets the fields of the desired spec on the spec
+// of an existing platform. The services with their persistence, the resources
+// of the builds, the registry and the eventing are owned by the orchestrator.
+// The other fields are only set when the orchestrator spec sets them, so the
+// defaults of the SonataFlow operator, such as the build strategy, its
+// options and the build timeout, are kept.
+func applySonataFlowPlatformSpec(live *sonataapi.SonataFlowPlatformSpec, desired sonataapi.SonataFlowPlatformSpec) {
+	live.Services = desired.Services
+	live.Eventing = desired.Eventing
+	live.Build.Template.Resources = desired.Build.Template.Resources
+	live.Build.Config.Registry = desired.Build.Config.Registry
+
+	if desired.Build.Template.Timeout != nil {
+		live.Build.Template.Timeout = desired.Build.Template.Timeout
+	}
+	if len(desired.Build.Template.Arguments) > 0 {
+		live.Build.Template.Arguments = desired.Build.Template.Arguments
+	}
+	if len(desired.Build.Template.Envs) > 0 {
+		live.Build.Template.Envs = desired.Build.Template.Envs
+	}
+	if desired.Build.Config.Timeout != nil {
+		live.Build.Config.Timeout = desired.Build.Config.Timeout
+	}
+	if desired.Build.Config.BuildStrategy != "" {
+		live.Build.Config.BuildStrategy = desired.Build.Config.BuildStrategy
+	}
+	if len(desired.Build.Config.BuildStrategyOptions) > 0 {
+		live.Build.Config.BuildStrategyOptions = desired.Build.Config.BuildStrategyOptions
+	}
+	if desired.Build.Config.BaseImage != "" {
+		live.Build.Config.BaseImage = desired.Build.Config.BaseImage
+	}
+	if desired.DevMode.BaseImage != "" {
+		live.DevMode.BaseImage = desired.DevMode.BaseImage
+	}
+}
+
+// getSonataFlowPlatformSpec returns the spec of the SonataFlowPlatform. The
+// resources are validated with the prerequisites of the component.
+func getSonataFlowPlatformSpec(spec orchestratorv1alpha1.OrchestratorSpec) sonataapi.SonataFlowPlatformSpec {
+	platform := spec.OrchestratorPlatform.SonataFlowPlatform
+	build := sonataapi.BuildPlatformSpec{
+		Template: sonataapi.BuildTemplate{
+			Timeout:   platform.Build.Timeout,
+			Resources: platform.Resources,
+			Arguments: platform.Build.Arguments,
+			Envs:      platform.Build.Envs,
+		},
+		Config: sonataapi.BuildPlatformConfig{
+			Timeout:              platform.Build.Timeout,
+			BuildStrategy:        sonataapi.BuildStrategy(platform.Build.Strategy),
+			BuildStrategyOptions: platform.Build.StrategyOptions,
+			BaseImage:            platform.Build.BaseImage,
+			Registry: sonataapi.RegistrySpec{
+				Address:      platform.Build.Registry.Address,
+				Organization: platform.Build.Registry.Organization,
+				Secret:       platform.Build.Registry.Secret,
+				CA:           platform.Build.Registry.CA,
+				Insecure:     platform.Build.Registry.Insecure,
 			},
 		},
 	}
+
+	platformSpec := sonataapi.SonataFlowPlatformSpec{
+		Build:   build,
+		DevMode: sonataapi.DevModePlatformSpec{BaseImage: platform.DevMode.BaseImage},
+		Services: &sonataapi.ServicesPlatformSpec{
+			DataIndex:  getPlatformServiceSpec(spec.PostgresDB, platform.DataIndex),
+			JobService: getPlatformServiceSpec(spec.PostgresDB, platform.JobService),
+		},
+	}
+	// without an explicit broker, the platform uses the first broker declared
+	// for the operator to create
+	broker := platform.Eventing.Broker
+	serverless := spec.ServerlessOperator
+	if (broker == nil || broker.Name == "") && serverless.Enabled && len(serverless.Brokers) > 0 {
+		broker = &orchestratorv1alpha1.BrokerReference{Name: serverless.Brokers[0].Name}
+	}
+	if broker != nil && broker.Name != "" {
+		namespace := broker.Namespace
+		if namespace == "" {
+			namespace = SonataFlowNamespace
+		}
+		platformSpec.Eventing = &sonataapi.PlatformEventingSpec{
+			Broker: &duckv1.Destination{Ref: &duckv1.KReference{
+				APIVersion: KnativeBrokerAPIVersion,
+				Kind:       KnativeBrokerKind,
+				Name:       broker.Name,
+				Namespace:  namespace,
+			}},
+		}
+	}
+	return platformSpec
+}
+
+// getPlatformServiceSpec returns the spec of a service of the platform. The
+// resources and pod template fields left empty keep the defaults of the
+// SonataFlow operator.
+func getPlatformServiceSpec(postgres orchestratorv1alpha1.Postgres, service orchestratorv1alpha1.PlatformService) *sonataapi.ServiceSpec {
+	persistence := getSonataFlowPersistence(postgres)
+	if service.Database != "" {
+		persistence.PostgreSQL.ServiceRef.DatabaseName = service.Database
+	}
+	persistence.PostgreSQL.ServiceRef.DatabaseSchema = service.Schema
+
+	spec := &sonataapi.ServiceSpec{
+		Enabled:     util.MakePointer(service.Enabled == nil || *service.Enabled),
+		Persistence: persistence,
+	}
+	spec.PodTemplate.Replicas = service.Replicas
+	spec.PodTemplate.Container.Image = service.PodTemplate.Image
+	spec.PodTemplate.Container.Env = service.PodTemplate.Env
+	spec.PodTemplate.Container.Resources = service.Resources
+	spec.PodTemplate.NodeSelector = service.PodTemplate.NodeSelector
+	spec.PodTemplate.Tolerations = service.PodTemplate.Tolerations
+	spec.PodTemplate.Affinity = service.PodTemplate.Affinity
+	spec.PodTemplate.ServiceAccountName = service.PodTemplate.ServiceAccountName
+	return spec
 }
 
-func handleSonataFlowCleanUp(ctx context.Context, client client.Client, olmClientSet olmclientset.Clientset) error {
+// validateResources returns an InvalidSpec error for the negative
+// quantities and the requests above their limits of the resources at the
+// given path of the spec. The quantities are parsed by the API server.
+func validateResources(path string, resources corev1.ResourceRequirements) error {
+	for _, field := range []struct {
+		name string
+		list corev1.ResourceList
+	}{{"limits", resources.Limits}, {"requests", resources.Requests}} {
+		for _, name := range sortedResourceNames(field.list) {
+			if quantity := field.list[name]; quantity.Sign() < 0 {
+				return kube.NewError(kube.InvalidSpec, "%s.%s.%s must not be negative, got %s", path, field.name, name, quantity.String())
+			}
+		}
+	}
+	for _, name := range sortedResourceNames(resources.Requests) {
+		request := resources.Requests[name]
+		limit, found := resources.Limits[name]
+		if found && request.Cmp(limit) > 0 {
+			return kube.NewError(kube.InvalidSpec, "%s.requests.%s %s must not exceed its limit %s", path, name, request.String(), limit.String())
+		}
+	}
+	return nil
+}
+
+func sortedResourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// validateSonataFlowPlatform checks the resources of the platform builds and
+// services.
+func validateSonataFlowPlatform(platform orchestratorv1alpha1.SonataFlowPlatform) error {
+	const path = "orchestrator.sonataFlowPlatform"
+	if err := validateResources(path+".resources", platform.Resources); err != nil {
+		return err
+	}
+	if err := validateResources(path+".dataIndex.resources", platform.DataIndex.Resources); err != nil {
+		return err
+	}
+	return validateResources(path+".jobService.resources", platform.JobService.Resources)
+}
+
+func handleSonataFlowCleanUp(ctx context.Context, client client.Client, olmClientSet olmclientset.Interface) error {
 	logger := log.FromContext(ctx)
 	// remove all namespace
 	if err := kube.CleanUpNamespace(ctx, SonataFlowNamespace, client); err != nil {
@@ -215,3 +376,64 @@ func handleSonataFlowCleanUp(ctx context.Context, client client.Client, olmClien
 	// remove all CRDs, optional (ensure all CRs and namespace have been removed first)
 	return nil
 }
+
+// sonataFlowComponent installs the SonataFlow operator and its platform.
+type sonataFlowComponent struct{}
+
+func (c *sonataFlowComponent) Name() string { return metrics.ComponentSonataFlow }
+
+func (c *sonataFlowComponent) ConditionType() string { return TypeSonataFlowReady }
+
+// DependsOn returns Knative and PostgreSQL, as the workflows run as Knative
+// services and persist their state in PostgreSQL, and the brokers the
+// platform may send its events to.
+func (c *sonataFlowComponent) DependsOn() []string {
+	return []string{metrics.ComponentKnative, metrics.ComponentBrokers, metrics.ComponentPostgreSQL}
+}
+
+func (c *sonataFlowComponent) Enabled(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.SonataFlowOperator.Enabled
+}
+
+func (c *sonataFlowComponent) Paused(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.SonataFlowOperator.Paused
+}
+
+func (c *sonataFlowComponent) Prerequisites(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	if err := validateSonataFlowPlatform(spec.OrchestratorPlatform.SonataFlowPlatform); err != nil {
+		return err
+	}
+	return ensureNamespace(ctx, env, spec.SonataFlowOperator.Subscription.Namespace)
+}
+
+func (c *sonataFlowComponent) Install(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	return installOperator(ctx, env, c.Name(), kube.OpenshiftServerlessOperatorGroupName, spec.SonataFlowOperator.Subscription)
+}
+
+func (c *sonataFlowComponent) Reconcile(
+	ctx context.Context,
+	env ComponentEnv,
+	spec orchestratorv1alpha1.OrchestratorSpec) (ComponentResult, error) {
+	logger := log.FromContext(ctx)
+	// wait until the operator serves the platform APIs
+	if err := checkAPIs(ctx, env, c.Name(), sonataFlowAPIs); err != nil {
+		return ComponentResult{}, err
+	}
+	if err := handleSonataFlowClusterCR(ctx, env.Client, SonataFlowClusterPlatformCRName); err != nil {
+		logger.Error(err, "Error occurred when creating SonataFlowClusterCR", "CR-Name", SonataFlowClusterPlatformCRName)
+		return ComponentResult{}, err
+	}
+	if err := handleSonataFlowPlatformCR(ctx, env.Client, spec, SonataFlowClusterPlatformCRName); err != nil {
+		logger.Error(err, "Error occurred when creating SonataFlowPlatform", "CR-Name", SonataFlowClusterPlatformCRName)
+		return ComponentResult{}, err
+	}
+	return ComponentResult{}, nil
+}
+
+func (c *sonataFlowComponent) Status(ctx context.Context, env ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) (ComponentStatus, error) {
+	return subscriptionStatus(ctx, env, spec.SonataFlowOperator.Subscription)
+}
+
+func (c *sonataFlowComponent) Cleanup(ctx context.Context, env ComponentEnv, _ orchestratorv1alpha1.OrchestratorSpec) error {
+	return handleSonataFlowCleanUp(ctx, env.Client, env.OLMClient)
+}
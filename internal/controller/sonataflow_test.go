@@ -0,0 +1,287 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	sonataapi "github.com/apache/incubator-kie-kogito-serverless-operator/api/v1alpha08"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/airgap"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("SonataFlowPlatform", func() {
+	var orchestrator *orchestratorv1alpha1.Orchestrator
+
+	BeforeEach(func() {
+		orchestrator = &orchestratorv1alpha1.Orchestrator{}
+		orchestrator.Spec.PostgresDB = orchestratorv1alpha1.Postgres{
+			ServiceName:      "sonataflow-psql-postgresql",
+			ServiceNameSpace: SonataFlowNamespace,
+			DatabaseName:     "sonataflow",
+		}
+	})
+
+	It("should enable both services on the platform database by default", func() {
+		services := getSonataFlowPlatformSpec(orchestrator.Spec).Services
+		for _, service := range []any{services.DataIndex, services.JobService} {
+			Expect(service).To(HaveField("Enabled", HaveValue(BeTrue())))
+			Expect(service).To(HaveField("Persistence.PostgreSQL.ServiceRef.DatabaseName", "sonataflow"))
+			Expect(service).To(HaveField("PodTemplate.Container.Resources", corev1.ResourceRequirements{}))
+		}
+	})
+
+	It("should configure each service independently", func() {
+		platform := &orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform
+		platform.DataIndex = orchestratorv1alpha1.PlatformService{
+			Database: "data-index",
+			Schema:   "instances",
+			Replicas: util.MakePointer(int32(2)),
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+				Limits: corev1.ResourceList{
+					corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+					"nvidia.com/gpu":                resource.MustParse("1"),
+				},
+			},
+			PodTemplate: orchestratorv1alpha1.PlatformServicePodTemplate{
+				Image:        "quay.io/myorg/data-index:1.0",
+				NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+			},
+		}
+		platform.JobService.Enabled = util.MakePointer(false)
+
+		services := getSonataFlowPlatformSpec(orchestrator.Spec).Services
+		dataIndex := services.DataIndex
+		Expect(*dataIndex.Enabled).To(BeTrue())
+		Expect(dataIndex.Persistence.PostgreSQL.ServiceRef.DatabaseName).To(Equal("data-index"))
+		Expect(dataIndex.Persistence.PostgreSQL.ServiceRef.DatabaseSchema).To(Equal("instances"))
+		Expect(*dataIndex.PodTemplate.Replicas).To(Equal(int32(2)))
+		Expect(dataIndex.PodTemplate.Container.Image).To(Equal("quay.io/myorg/data-index:1.0"))
+		Expect(dataIndex.PodTemplate.Container.Resources).To(Equal(corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			Limits: corev1.ResourceList{
+				corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+				"nvidia.com/gpu":                resource.MustParse("1"),
+			},
+		}))
+		Expect(dataIndex.PodTemplate.NodeSelector).To(HaveKey("node-role.kubernetes.io/infra"))
+
+		Expect(*services.JobService.Enabled).To(BeFalse())
+		Expect(services.JobService.Persistence.PostgreSQL.ServiceRef.DatabaseName).To(Equal("sonataflow"))
+	})
+
+	It("should leave the omitted resources of the builds unset", func() {
+		platform := &orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform
+		platform.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+
+		resources := getSonataFlowPlatformSpec(orchestrator.Spec).Build.Template.Resources
+		Expect(resources.Limits).To(Equal(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}))
+		Expect(resources.Requests).To(BeEmpty())
+	})
+
+	It("should reject the invalid resources", func() {
+		platform := orchestratorv1alpha1.SonataFlowPlatform{}
+		Expect(validateSonataFlowPlatform(platform)).To(Succeed())
+
+		platform.DataIndex.Resources = corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		}
+		err := validateSonataFlowPlatform(platform)
+		Expect(kube.ErrorKindOf(err)).To(Equal(kube.InvalidSpec))
+		Expect(err).To(MatchError("orchestrator.sonataFlowPlatform.dataIndex.resources.requests.cpu 2 must not exceed its limit 500m"))
+
+		platform.DataIndex.Resources = corev1.ResourceRequirements{}
+		platform.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("-1Gi")}
+		Expect(kube.IsTerminal(validateSonataFlowPlatform(platform))).To(BeTrue())
+	})
+
+	It("should configure the builds, the dev mode and the eventing of the platform", func() {
+		platform := &orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform
+		platform.Build = orchestratorv1alpha1.PlatformBuild{
+			Registry:        orchestratorv1alpha1.PlatformBuildRegistry{Address: "registry.example.com:5000", Organization: "workflows", Secret: "registry-credentials"},
+			BaseImage:       "registry.example.com:5000/builder:1.0",
+			Arguments:       []string{"QUARKUS_EXTENSIONS=org.acme:extension:1.0"},
+			Strategy:        "platform",
+			StrategyOptions: map[string]string{"KanikoBuildCacheEnabled": "true"},
+		}
+		platform.DevMode.BaseImage = "registry.example.com:5000/devmode:1.0"
+		platform.Eventing.Broker = &orchestratorv1alpha1.BrokerReference{Name: "default"}
+
+		spec := getSonataFlowPlatformSpec(orchestrator.Spec)
+		Expect(spec.Build.Config.Registry.Address).To(Equal("registry.example.com:5000"))
+		Expect(spec.Build.Config.Registry.Organization).To(Equal("workflows"))
+		Expect(spec.Build.Config.Registry.Secret).To(Equal("registry-credentials"))
+		Expect(spec.Build.Config.BaseImage).To(Equal("registry.example.com:5000/builder:1.0"))
+		Expect(spec.Build.Config.BuildStrategy).To(Equal(sonataapi.PlatformBuildStrategy))
+		Expect(spec.Build.Config.BuildStrategyOptions).To(HaveKeyWithValue("KanikoBuildCacheEnabled", "true"))
+		Expect(spec.Build.Template.Arguments).To(Equal([]string{"QUARKUS_EXTENSIONS=org.acme:extension:1.0"}))
+		Expect(spec.DevMode.BaseImage).To(Equal("registry.example.com:5000/devmode:1.0"))
+		Expect(spec.Eventing.Broker.Ref.Kind).To(Equal(KnativeBrokerKind))
+		Expect(spec.Eventing.Broker.Ref.Name).To(Equal("default"))
+		Expect(spec.Eventing.Broker.Ref.Namespace).To(Equal(SonataFlowNamespace))
+
+	})
+
+	It("should build and pull the images through the mirror registry when air-gapped", func() {
+		platform := &orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform
+		platform.Build.BaseImage = "registry.redhat.io/openshift-serverless-1/logic-swf-builder-rhel8:1.33"
+		platform.DataIndex.PodTemplate.Image = "registry.redhat.io/openshift-serverless-1/logic-data-index-postgresql-rhel8:1.33"
+		orchestrator.Spec.AirGapped = orchestratorv1alpha1.AirGapped{Enabled: true, MirrorRegistry: "mirror.example.com"}
+
+		applied, _ := airgap.Apply(orchestrator.Spec)
+		spec := getSonataFlowPlatformSpec(applied)
+		Expect(spec.Build.Config.Registry.Address).To(Equal("mirror.example.com"))
+		Expect(spec.Build.Config.BaseImage).To(Equal("mirror.example.com/openshift-serverless-1/logic-swf-builder-rhel8:1.33"))
+		Expect(spec.Services.DataIndex.PodTemplate.Container.Image).To(Equal(
+			"mirror.example.com/openshift-serverless-1/logic-data-index-postgresql-rhel8:1.33"))
+
+		// the registry of the spec takes precedence over the mirror registry
+		platform.Build.Registry.Address = "registry.example.com:5000"
+		applied, _ = airgap.Apply(orchestrator.Spec)
+		spec = getSonataFlowPlatformSpec(applied)
+		Expect(spec.Build.Config.Registry.Address).To(Equal("registry.example.com:5000"))
+	})
+})
+
+var _ = Describe("SonataFlowPlatform updates", func() {
+	ctx := context.Background()
+	var k8sClient client.Client
+	var updates int
+	var orchestrator *orchestratorv1alpha1.Orchestrator
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(sonataapi.AddToScheme(scheme)).To(Succeed())
+		updates = 0
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					updates++
+					return c.Update(ctx, obj, opts...)
+				},
+			}).
+			Build()
+		orchestrator = &orchestratorv1alpha1.Orchestrator{}
+		Expect(handleSonataFlowPlatformCR(ctx, k8sClient, orchestrator.Spec, SonataFlowPlatformCRName)).To(Succeed())
+	})
+
+	getPlatform := func() *sonataapi.SonataFlowPlatform {
+		platform := &sonataapi.SonataFlowPlatform{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: SonataFlowPlatformCRName}, platform)).To(Succeed())
+		return platform
+	}
+
+	It("should apply the service settings to an existing platform", func() {
+		Expect(handleSonataFlowPlatformCR(ctx, k8sClient, orchestrator.Spec, SonataFlowPlatformCRName)).To(Succeed())
+		Expect(updates).To(BeZero())
+
+		orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform.DataIndex.Replicas = util.MakePointer(int32(2))
+		orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform.JobService.Enabled = util.MakePointer(false)
+		Expect(handleSonataFlowPlatformCR(ctx, k8sClient, orchestrator.Spec, SonataFlowPlatformCRName)).To(Succeed())
+		Expect(updates).To(Equal(1))
+		platform := getPlatform()
+		Expect(*platform.Spec.Services.DataIndex.PodTemplate.Replicas).To(Equal(int32(2)))
+		Expect(*platform.Spec.Services.JobService.Enabled).To(BeFalse())
+	})
+
+	It("should apply the build, dev mode and eventing settings to an existing platform", func() {
+		platformSpec := &orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform
+		platformSpec.Build.Registry.Address = "quay.io"
+		platformSpec.Build.Registry.Organization = "workflows"
+		platformSpec.DevMode.BaseImage = "quay.io/workflows/devmode:latest"
+		platformSpec.Eventing.Broker = &orchestratorv1alpha1.BrokerReference{Name: "kafka-broker", Namespace: "knative-eventing"}
+		Expect(handleSonataFlowPlatformCR(ctx, k8sClient, orchestrator.Spec, SonataFlowPlatformCRName)).To(Succeed())
+		Expect(updates).To(Equal(1))
+
+		platform := getPlatform()
+		Expect(platform.Spec.Build.Config.Registry.Address).To(Equal("quay.io"))
+		Expect(platform.Spec.Build.Config.Registry.Organization).To(Equal("workflows"))
+		Expect(platform.Spec.DevMode.BaseImage).To(Equal("quay.io/workflows/devmode:latest"))
+		Expect(platform.Spec.Eventing).NotTo(BeNil())
+		Expect(platform.Spec.Eventing.Broker.Ref.Name).To(Equal("kafka-broker"))
+		Expect(platform.Spec.Eventing.Broker.Ref.Namespace).To(Equal("knative-eventing"))
+
+		platformSpec.Eventing.Broker = nil
+		Expect(handleSonataFlowPlatformCR(ctx, k8sClient, orchestrator.Spec, SonataFlowPlatformCRName)).To(Succeed())
+		Expect(getPlatform().Spec.Eventing).To(BeNil())
+	})
+
+	It("should keep the fields defaulted by the SonataFlow operator", func() {
+		platform := getPlatform()
+		platform.Spec.Build.Config.BuildStrategy = "platform"
+		platform.Spec.Build.Config.BuildStrategyOptions = map[string]string{"KanikoBuildCacheEnabled": "true"}
+		platform.Spec.Build.Config.Timeout = &metav1.Duration{Duration: 5 * time.Minute}
+		Expect(k8sClient.Update(ctx, platform)).To(Succeed())
+		updates = 0
+
+		Expect(handleSonataFlowPlatformCR(ctx, k8sClient, orchestrator.Spec, SonataFlowPlatformCRName)).To(Succeed())
+		Expect(updates).To(BeZero())
+
+		// the owned fields are still updated, and the fields of the spec override the defaults
+		platformSpec := &orchestrator.Spec.OrchestratorPlatform.SonataFlowPlatform
+		platformSpec.Build.Registry.Address = "quay.io"
+		platformSpec.Build.Strategy = "operator"
+		Expect(handleSonataFlowPlatformCR(ctx, k8sClient, orchestrator.Spec, SonataFlowPlatformCRName)).To(Succeed())
+		Expect(updates).To(Equal(1))
+		platform = getPlatform()
+		Expect(platform.Spec.Build.Config.Registry.Address).To(Equal("quay.io"))
+		Expect(platform.Spec.Build.Config.BuildStrategy).To(BeEquivalentTo("operator"))
+		Expect(platform.Spec.Build.Config.BuildStrategyOptions).To(HaveKeyWithValue("KanikoBuildCacheEnabled", "true"))
+		Expect(platform.Spec.Build.Config.Timeout.Duration).To(Equal(5 * time.Minute))
+	})
+})
+
+var _ = Describe("SonataFlowClusterPlatform", func() {
+	ctx := context.Background()
+
+	It("should return the error of a failed update", func() {
+		scheme := runtime.NewScheme()
+		Expect(sonataapi.AddToScheme(scheme)).To(Succeed())
+		stale := &sonataapi.SonataFlowClusterPlatform{
+			ObjectMeta: metav1.ObjectMeta{Namespace: SonataFlowNamespace, Name: SonataFlowClusterPlatformCRName},
+		}
+		updateErr := errors.New("conflict")
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).
+			WithObjects(stale).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(context.Context, client.WithWatch, client.Object, ...client.UpdateOption) error {
+					return updateErr
+				},
+			}).
+			Build()
+
+		Expect(handleSonataFlowClusterCR(ctx, k8sClient, SonataFlowClusterPlatformCRName)).To(MatchError(updateErr))
+	})
+})
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	sonataapi "github.com/apache/incubator-kie-kogito-serverless-operator/api/v1alpha08"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/types"
+	knative "knative.dev/operator/pkg/apis/operator/v1beta1"
+	backstagev1alpha1 "redhat-developer/red-hat-developer-hub-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// crdWatch is a watch on the resources of a CRD installed by one of the
+// operators, registered once the CRD is established. Without an object, the
+// orchestrators are only reconciled when the CRD is established.
+type crdWatch struct {
+	object client.Object
+	// byNamespace maps the objects to the orchestrators subscribing an
+	// operator in their namespace, instead of the created-by label.
+	byNamespace bool
+}
+
+// crdWatches are the watches registered when their CRD appears, as the
+// operators defining them are usually installed by the orchestrator itself.
+var crdWatches = map[string]crdWatch{
+	"subscriptions.operators.coreos.com":          {object: &operatorsv1alpha1.Subscription{}, byNamespace: true},
+	"clusterserviceversions.operators.coreos.com": {object: &operatorsv1alpha1.ClusterServiceVersion{}, byNamespace: true},
+	"sonataflowplatforms.sonataflow.org":          {object: &sonataapi.SonataFlowPlatform{}},
+	SonataFlowClusterPlatformCRDName:              {object: &sonataapi.SonataFlowClusterPlatform{}},
+	SonataFlowCRDName:                             {object: &sonataapi.SonataFlow{}},
+	KnativeServingCRDName:                         {object: &knative.KnativeServing{}},
+	KnativeEventingCRDName:                        {object: &knative.KnativeEventing{}},
+	KnativeBrokerCRDName:                          {object: newBroker()},
+	KnativeTriggerCRDName:                         {object: newTrigger()},
+	KafkaSinkCRDName:                              {},
+	"backstages.rhdh.redhat.com":                  {object: &backstagev1alpha1.Backstage{}},
+}
+
+// dynamicWatches registers the watches of crdWatches on the controller.
+type dynamicWatches struct {
+	controller controller.Controller
+	cache      cache.Cache
+
+	mu         sync.Mutex
+	registered map[string]bool
+}
+
+func newDynamicWatches(controller controller.Controller, cache cache.Cache) *dynamicWatches {
+	return &dynamicWatches{controller: controller, cache: cache, registered: map[string]bool{}}
+}
+
+// ensure registers the watch of the CRD once it is established.
+func (w *dynamicWatches) ensure(crd *apiextensionsv1.CustomResourceDefinition, mapFunc func(crdWatch) handler.MapFunc) error {
+	watch, found := crdWatches[crd.Name]
+	if !found || watch.object == nil || !crdEstablished(crd) {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.registered[crd.Name] {
+		return nil
+	}
+	predicates := []predicate.Predicate{}
+	if !watch.byNamespace {
+		predicates = append(predicates, createdByPredicate)
+	}
+	src := source.Kind(w.cache, watch.object, handler.EnqueueRequestsFromMapFunc(mapFunc(watch)), predicates...)
+	if err := w.controller.Watch(src); err != nil {
+		return err
+	}
+	w.registered[crd.Name] = true
+	return nil
+}
+
+func crdEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextensionsv1.Established {
+			return condition.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// createdByPredicate keeps the objects labeled as created by the orchestrator.
+var createdByPredicate = predicate.NewPredicateFuncs(func(object client.Object) bool {
+	return object.GetLabels()[kube.CreatedByLabelKey] == kube.CreatedByLabelValue
+})
+
+// workflowSourcePredicate keeps the ConfigMaps of the users in the namespace
+// of the workflows, which may hold their definitions.
+var workflowSourcePredicate = predicate.NewPredicateFuncs(func(object client.Object) bool {
+	return object.GetNamespace() == SonataFlowNamespace && object.GetLabels()[kube.CreatedByLabelKey] != kube.CreatedByLabelValue
+})
+
+// watchedCRDPredicate keeps the CRDs of crdWatches.
+var watchedCRDPredicate = predicate.NewPredicateFuncs(func(object client.Object) bool {
+	_, found := crdWatches[object.GetName()]
+	return found
+})
+
+// mapCRD registers the watch of an established CRD and reconciles the
+// orchestrators, which may have skipped the resources of the missing CRD.
+func (r *OrchestratorReconciler) mapCRD(ctx context.Context, object client.Object) []reconcile.Request {
+	crd, ok := object.(*apiextensionsv1.CustomResourceDefinition)
+	if !ok {
+		return nil
+	}
+	if err := r.watches.ensure(crd, r.watchMapFunc); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to watch CRD resources", "CRD", crd.Name)
+		return nil
+	}
+	return r.mapToOrchestrators(ctx, object)
+}
+
+func (r *OrchestratorReconciler) watchMapFunc(watch crdWatch) handler.MapFunc {
+	if watch.byNamespace {
+		return r.mapNamespaceToOrchestrators
+	}
+	return r.mapToOrchestrators
+}
+
+// mapToOrchestrators reconciles the orchestrator recorded as the owner of the
+// object. The objects without an owner, such as the CRDs or the resources
+// written by an older operator, reconcile all the orchestrators.
+func (r *OrchestratorReconciler) mapToOrchestrators(ctx context.Context, object client.Object) []reconcile.Request {
+	if owner, found := kube.OwnerOf(object); found {
+		return []reconcile.Request{{NamespacedName: owner}}
+	}
+	return r.orchestratorRequests(ctx, func(orchestratorv1alpha1.Orchestrator) bool { return true })
+}
+
+// mapNamespaceToOrchestrators reconciles the orchestrators subscribing an
+// operator in the namespace of the object.
+func (r *OrchestratorReconciler) mapNamespaceToOrchestrators(ctx context.Context, object client.Object) []reconcile.Request {
+	return r.orchestratorRequests(ctx, func(orchestrator orchestratorv1alpha1.Orchestrator) bool {
+		return slices.Contains(subscriptionNamespaces(orchestrator.Spec), object.GetNamespace())
+	})
+}
+
+// mapWorkflowSourceToOrchestrators reconciles the orchestrators declaring a
+// workflow defined in the ConfigMap.
+func (r *OrchestratorReconciler) mapWorkflowSourceToOrchestrators(ctx context.Context, object client.Object) []reconcile.Request {
+	return r.orchestratorRequests(ctx, func(orchestrator orchestratorv1alpha1.Orchestrator) bool {
+		return slices.ContainsFunc(orchestrator.Spec.Workflows, func(workflow orchestratorv1alpha1.Workflow) bool {
+			return workflow.ConfigMap != nil && workflow.ConfigMap.Name == object.GetName()
+		})
+	})
+}
+
+func (r *OrchestratorReconciler) orchestratorRequests(
+	ctx context.Context,
+	filter func(orchestratorv1alpha1.Orchestrator) bool) []reconcile.Request {
+	orchestrators := &orchestratorv1alpha1.OrchestratorList{}
+	if err := r.List(ctx, orchestrators); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list orchestrators")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, orchestrator := range orchestrators.Items {
+		if filter(orchestrator) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: orchestrator.Namespace, Name: orchestrator.Name},
+			})
+		}
+	}
+	return requests
+}
+
+func subscriptionNamespaces(spec orchestratorv1alpha1.OrchestratorSpec) []string {
+	return []string{
+		spec.SonataFlowOperator.Subscription.Namespace,
+		spec.ServerlessOperator.Subscription.Namespace,
+		spec.RhdhOperator.Subscription.Namespace,
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Watches", func() {
+	ctx := context.Background()
+	var r *OrchestratorReconciler
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(orchestratorv1alpha1.AddToScheme(scheme)).To(Succeed())
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "orchestrator"}},
+			&orchestratorv1alpha1.Orchestrator{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "orchestrator"}},
+		).Build()
+		r = &OrchestratorReconciler{Client: k8sClient}
+	})
+
+	It("should map the written resources back to their orchestrator", func() {
+		owner := client.ObjectKey{Namespace: "team-a", Name: "orchestrator"}
+		ownerClient := kube.NewOwnerClient(r.Client, owner)
+		config := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "sonataflow-infra", Name: "config", Labels: kube.AddLabel()}}
+		Expect(ownerClient.Create(ctx, config)).To(Succeed())
+		Expect(config.Annotations).To(HaveKeyWithValue(kube.OwnerAnnotationKey, "team-a/orchestrator"))
+
+		Expect(r.mapToOrchestrators(ctx, config)).To(Equal([]reconcile.Request{{NamespacedName: owner}}))
+	})
+
+	It("should leave the resources not created by the orchestrator unchanged", func() {
+		ownerClient := kube.NewOwnerClient(r.Client, client.ObjectKey{Namespace: "team-a", Name: "orchestrator"})
+		config := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "sonataflow-infra", Name: "user-config"}}
+		Expect(ownerClient.Create(ctx, config)).To(Succeed())
+		Expect(config.Annotations).NotTo(HaveKey(kube.OwnerAnnotationKey))
+	})
+
+	It("should map the workflow definitions to the orchestrators declaring them", func() {
+		orchestrator := &orchestratorv1alpha1.Orchestrator{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "team-b", Name: "orchestrator"}, orchestrator)).To(Succeed())
+		orchestrator.Spec.Workflows = []orchestratorv1alpha1.Workflow{{
+			Name:      "greeting",
+			ConfigMap: &orchestratorv1alpha1.WorkflowConfigMapSource{Name: "greeting-workflow"},
+		}}
+		Expect(r.Update(ctx, orchestrator)).To(Succeed())
+
+		definition := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: SonataFlowNamespace, Name: "greeting-workflow"}}
+		Expect(workflowSourcePredicate.Generic(event.GenericEvent{Object: definition})).To(BeTrue())
+		Expect(r.mapWorkflowSourceToOrchestrators(ctx, definition)).To(Equal([]reconcile.Request{
+			{NamespacedName: client.ObjectKey{Namespace: "team-b", Name: "orchestrator"}},
+		}))
+	})
+
+	It("should reconcile all the orchestrators for the resources without an owner", func() {
+		config := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "sonataflow-infra", Name: "config", Labels: kube.AddLabel()}}
+		Expect(r.mapToOrchestrators(ctx, config)).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,330 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	sonataapi "github.com/apache/incubator-kie-kogito-serverless-operator/api/v1alpha08"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/airgap"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// WorkflowLabelKey labels the resources created for a workflow with its
+	// name, so they are pruned with it.
+	WorkflowLabelKey = "rhdh.redhat.com/workflow"
+	// The credentials of the database role of a workflow are stored in the
+	// Secret named after the workflow with WorkflowCredentialsSuffix.
+	WorkflowCredentialsSuffix   = "-db-credentials"
+	WorkflowDatabaseUserKey     = "username"
+	WorkflowDatabasePasswordKey = "password"
+	// The schema and the role of a workflow are created by the Job named
+	// after the workflow with WorkflowDatabaseSetupSuffix.
+	WorkflowDatabaseSetupSuffix = "-db-setup"
+	// The role and the schema of a workflow are named after the workflow with
+	// WorkflowDatabasePrefix, so they don't clash with the other roles.
+	WorkflowDatabasePrefix = "wf_"
+	PostgresDefaultPort    = 5432
+	// PostgresClientImageEnv overrides the default image of the setup Jobs.
+	// The bundle generated with image digests pins it in the manager
+	// deployment, and lists it in the related images to mirror.
+	PostgresClientImageEnv = "RELATED_IMAGE_POSTGRES_CLIENT"
+
+	// workflowDatabaseHashAnnotation records the inputs of the setup Job,
+	// which is recreated when they change as its template is immutable.
+	workflowDatabaseHashAnnotation = "rhdh.redhat.com/db-setup-hash"
+)
+
+// PostgresClientImage is the image of the setup Jobs when the spec sets none.
+var PostgresClientImage = postgresClientImage()
+
+func postgresClientImage() string {
+	if image := os.Getenv(PostgresClientImageEnv); image != "" {
+		return image
+	}
+	return "registry.redhat.io/rhel9/postgresql-15:latest"
+}
+
+// applySpec returns the spec reconciled by the components: the default image
+// of the setup Jobs is set, so the air-gapped profile pulls it from the
+// mirror registry like the other images, before the profile is applied.
+func applySpec(spec orchestratorv1alpha1.OrchestratorSpec) (orchestratorv1alpha1.OrchestratorSpec, []string) {
+	if spec.PostgresDB.ClientImage == "" {
+		spec.PostgresDB.ClientImage = PostgresClientImage
+	}
+	return airgap.Apply(spec)
+}
+
+// workflowDatabaseSetupSQL creates the role and the schema of a workflow. The
+// schema is owned by the role and not usable by the other roles, so a
+// workflow can't read the state of the others. The role is marked with the
+// workflowDatabaseRoleComment when created, and the setup fails for a role or
+// a schema the operator did not create. psql reads the user, password and
+// schema variables from the environment with \getenv, available from psql
+// 15, so the password doesn't show in the arguments of the process.
+const workflowDatabaseSetupSQL = `\getenv user WORKFLOW_USER
+\getenv password WORKFLOW_PASSWORD
+\getenv schema WORKFLOW_SCHEMA
+SELECT format('CREATE ROLE %I LOGIN', :'user'), format('COMMENT ON ROLE %I IS %L', :'user', '` + workflowDatabaseRoleComment + `') WHERE NOT EXISTS (SELECT FROM pg_roles WHERE rolname = :'user') \gexec
+SELECT format('DO $$BEGIN RAISE EXCEPTION %L; END$$', 'role ' || rolname || ' was not created by the orchestrator operator') FROM pg_roles WHERE rolname = :'user' AND shobj_description(oid, 'pg_authid') IS DISTINCT FROM '` + workflowDatabaseRoleComment + `' \gexec
+SELECT format('DO $$BEGIN RAISE EXCEPTION %L; END$$', 'schema ' || nspname || ' is not owned by role ' || :'user') FROM pg_namespace WHERE nspname = :'schema' AND nspowner <> (SELECT oid FROM pg_roles WHERE rolname = :'user') \gexec
+ALTER ROLE :"user" WITH LOGIN PASSWORD :'password';
+CREATE SCHEMA IF NOT EXISTS :"schema" AUTHORIZATION :"user";
+REVOKE ALL ON SCHEMA :"schema" FROM PUBLIC;
+ALTER ROLE :"user" SET search_path TO :"schema";
+`
+
+// workflowDatabaseRoleComment marks the roles created by the operator.
+const workflowDatabaseRoleComment = "created by the orchestrator operator"
+
+// workflowDatabaseNotReadyError is returned, as a MissingPrerequisite, while
+// the setup Job of a workflow has not completed.
+type workflowDatabaseNotReadyError struct {
+	workflow string
+	job      string
+}
+
+func (e *workflowDatabaseNotReadyError) Error() string {
+	return fmt.Sprintf("waiting for Job %s/%s to set up the database schema of workflow %s", SonataFlowNamespace, e.job, e.workflow)
+}
+
+// workflowDatabaseSchema returns the schema of the workflow in the platform
+// database, also used as the name of its role. The workflow names are at most
+// WorkflowNameMaxLength long, so it fits in the 63 bytes of the Postgres
+// identifiers.
+func workflowDatabaseSchema(workflow string) string {
+	return WorkflowDatabasePrefix + strings.ReplaceAll(workflow, "-", "_")
+}
+
+func workflowLabels(workflow string) map[string]string {
+	labels := kube.AddLabel()
+	labels[WorkflowLabelKey] = workflow
+	return labels
+}
+
+// getWorkflowPersistence returns the persistence of the workflow: the
+// platform database with the schema and the credentials of the workflow.
+func getWorkflowPersistence(postgres orchestratorv1alpha1.Postgres, workflow string) *sonataapi.PersistenceOptionsSpec {
+	persistence := getSonataFlowPersistence(postgres)
+	persistence.PostgreSQL.SecretRef.Name = workflow + WorkflowCredentialsSuffix
+	persistence.PostgreSQL.SecretRef.UserKey = WorkflowDatabaseUserKey
+	persistence.PostgreSQL.SecretRef.PasswordKey = WorkflowDatabasePasswordKey
+	persistence.PostgreSQL.ServiceRef.DatabaseSchema = workflowDatabaseSchema(workflow)
+	return persistence
+}
+
+// handleWorkflowCredentials creates the Secret with the credentials of the
+// database role of the workflow. The password is generated once and kept.
+func handleWorkflowCredentials(ctx context.Context, c client.Client, workflow string) error {
+	logger := log.FromContext(ctx)
+	name := workflow + WorkflowCredentialsSuffix
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: SonataFlowNamespace, Name: name}, secret)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		logger.Error(err, "Error occurred when checking secret exist", "Secret", name)
+		return err
+	}
+	password := make([]byte, 24)
+	if _, err := rand.Read(password); err != nil {
+		return err
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: SonataFlowNamespace,
+			Labels:    workflowLabels(workflow),
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			WorkflowDatabaseUserKey:     workflowDatabaseSchema(workflow),
+			WorkflowDatabasePasswordKey: hex.EncodeToString(password),
+		},
+	}
+	if err := c.Create(ctx, secret); err != nil {
+		logger.Error(err, "Error occurred when creating secret", "Secret", name)
+		kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create Secret %s/%s: %v", SonataFlowNamespace, name, err)
+		return err
+	}
+	kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created Secret %s/%s", SonataFlowNamespace, name)
+	return nil
+}
+
+// getWorkflowDatabaseSetupJob returns the Job creating the role and the
+// schema of the workflow with the credentials of the platform database.
+func getWorkflowDatabaseSetupJob(postgres orchestratorv1alpha1.Postgres, workflow string) *batchv1.Job {
+	image := postgres.ClientImage
+	if image == "" {
+		image = PostgresClientImage
+	}
+	credentials := workflow + WorkflowCredentialsSuffix
+	secretEnv := func(name, secret, key string) corev1.EnvVar {
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+			Key:                  key,
+		}}}
+	}
+	env := []corev1.EnvVar{
+		{Name: "PGHOST", Value: fmt.Sprintf("%s.%s.svc", postgres.ServiceName, postgres.ServiceNameSpace)},
+		{Name: "PGPORT", Value: fmt.Sprint(PostgresDefaultPort)},
+		{Name: "PGDATABASE", Value: postgres.DatabaseName},
+		secretEnv("PGUSER", postgres.AuthSecret.SecretName, postgres.AuthSecret.UserKey),
+		secretEnv("PGPASSWORD", postgres.AuthSecret.SecretName, postgres.AuthSecret.PasswordKey),
+		secretEnv("WORKFLOW_USER", credentials, WorkflowDatabaseUserKey),
+		secretEnv("WORKFLOW_PASSWORD", credentials, WorkflowDatabasePasswordKey),
+		{Name: "WORKFLOW_SCHEMA", Value: workflowDatabaseSchema(workflow)},
+		{Name: "SETUP_SQL", Value: workflowDatabaseSetupSQL},
+	}
+
+	inputs := sha256.New()
+	fmt.Fprintln(inputs, image)
+	for _, variable := range env {
+		fmt.Fprintln(inputs, variable.String())
+	}
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        workflow + WorkflowDatabaseSetupSuffix,
+			Namespace:   SonataFlowNamespace,
+			Labels:      workflowLabels(workflow),
+			Annotations: map[string]string{workflowDatabaseHashAnnotation: hex.EncodeToString(inputs.Sum(nil))},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To[int32](3),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: workflowLabels(workflow)},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:  "setup",
+						Image: image,
+						Command: []string{"/bin/sh", "-c",
+							`printf '%s\n' "$SETUP_SQL" | psql -v ON_ERROR_STOP=1`},
+						Env: env,
+					}},
+				},
+			},
+		},
+	}
+}
+
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// handleWorkflowDatabase creates the credentials and runs the setup Job of
+// the database schema of the workflow. It returns a
+// workflowDatabaseNotReadyError until the Job has completed. A failed Job,
+// such as one refusing a role the operator did not create, is an InvalidSpec
+// error: it is deleted, so it runs again once the spec changes.
+func handleWorkflowDatabase(ctx context.Context, c client.Client, postgres orchestratorv1alpha1.Postgres, workflow string) error {
+	logger := log.FromContext(ctx)
+	if err := handleWorkflowCredentials(ctx, c, workflow); err != nil {
+		return err
+	}
+	desired := getWorkflowDatabaseSetupJob(postgres, workflow)
+	notReady := kube.WrapError(kube.MissingPrerequisite, &workflowDatabaseNotReadyError{workflow: workflow, job: desired.Name})
+
+	job := &batchv1.Job{}
+	err := c.Get(ctx, client.ObjectKeyFromObject(desired), job)
+	if apierrors.IsNotFound(err) {
+		if err := c.Create(ctx, desired); err != nil {
+			logger.Error(err, "Error occurred when creating Job", "Job", desired.Name)
+			kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create Job %s/%s: %v", desired.Namespace, desired.Name, err)
+			return err
+		}
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created Job %s/%s", desired.Namespace, desired.Name)
+		return notReady
+	}
+	if err != nil {
+		logger.Error(err, "Error occurred when retrieving Job", "Job", desired.Name)
+		return err
+	}
+
+	changed := job.Annotations[workflowDatabaseHashAnnotation] != desired.Annotations[workflowDatabaseHashAnnotation]
+	failed := jobCondition(job, batchv1.JobFailed)
+	if !changed && !failed {
+		if jobCondition(job, batchv1.JobComplete) {
+			return nil
+		}
+		return notReady
+	}
+	if err := c.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "Error occurred when deleting Job", "Job", job.Name)
+		return err
+	}
+	if failed {
+		kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed,
+			"Job %s/%s failed to set up the database schema of workflow %s", job.Namespace, job.Name, workflow)
+		return kube.NewError(kube.InvalidSpec, "Job %s/%s failed to set up the database schema of workflow %s", job.Namespace, job.Name, workflow)
+	}
+	kube.EventsFromContext(ctx).Normal(kube.ReasonResourceDeleted, "Deleted Job %s/%s to apply the updated database settings", job.Namespace, job.Name)
+	return notReady
+}
+
+// pruneWorkflowResources deletes the Jobs, Secrets and ConfigMaps created for
+// the workflows that are no longer declared. The schemas and the roles are
+// kept in the database with the state of the workflows.
+func pruneWorkflowResources(ctx context.Context, c client.Client, declared map[string]bool) error {
+	lists := []client.ObjectList{&batchv1.JobList{}, &corev1.SecretList{}, &corev1.ConfigMapList{}}
+	for _, list := range lists {
+		if err := c.List(ctx, list, client.InNamespace(SonataFlowNamespace),
+			client.MatchingLabels(kube.AddLabel()), client.HasLabels{WorkflowLabelKey}); err != nil {
+			return err
+		}
+		objects, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range objects {
+			object := item.(client.Object)
+			if declared[object.GetLabels()[WorkflowLabelKey]] {
+				continue
+			}
+			err := c.Delete(ctx, object, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !apierrors.IsNotFound(err) {
+				log.FromContext(ctx).Error(err, "Error occurred when deleting workflow resource", "Name", object.GetName())
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,542 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sonataapi "github.com/apache/incubator-kie-kogito-serverless-operator/api/v1alpha08"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	SonataFlowKind    = "SonataFlow"
+	SonataFlowCRDName = "sonataflows.sonataflow.org"
+	// WorkflowPropertiesKey is the key of the application properties in the
+	// properties ConfigMap of a workflow, named after the workflow with the
+	// WorkflowPropertiesSuffix.
+	WorkflowPropertiesKey    = "application.properties"
+	WorkflowPropertiesSuffix = "-props"
+	// WorkflowNameMaxLength keeps the names derived from the workflow name
+	// within 63 characters, as the CRD validation does.
+	WorkflowNameMaxLength = 63 - len(WorkflowDatabaseSetupSuffix)
+
+	// annotations read by the SonataFlow operator
+	sonataFlowProfileAnnotation     = "sonataflow.org/profile"
+	sonataFlowVersionAnnotation     = "sonataflow.org/version"
+	sonataFlowDescriptionAnnotation = "sonataflow.org/description"
+	// the gitops profile deploys the image of the workflow, the preview
+	// profile builds it on the platform
+	sonataFlowGitOpsProfile  = "gitops"
+	sonataFlowPreviewProfile = "preview"
+)
+
+// WorkflowDefinitionClient is the HTTP client used to fetch the workflow
+// definitions from Git.
+var WorkflowDefinitionClient = &http.Client{Timeout: 30 * time.Second}
+
+// WorkflowDefinitionRefreshPeriod is how long a definition fetched from Git is
+// used before fetching it again.
+var WorkflowDefinitionRefreshPeriod = 10 * time.Minute
+
+// workflowDefinition holds the fields of a workflow definition that are not
+// part of the flow of the SonataFlow.
+type workflowDefinition struct {
+	Version     string `json:"version,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// validateWorkflows checks that each workflow has a unique name, short enough
+// for the names derived from it, and at most one definition source. A workflow without a definition is deployed from its
+// image, which holds the definition.
+func validateWorkflows(workflows []orchestratorv1alpha1.Workflow) error {
+	names := map[string]bool{}
+	for _, workflow := range workflows {
+		if names[workflow.Name] {
+			return kube.NewError(kube.InvalidSpec, "workflow %s is declared more than once", workflow.Name)
+		}
+		names[workflow.Name] = true
+		if len(workflow.Name) > WorkflowNameMaxLength {
+			return kube.NewError(kube.InvalidSpec, "workflow %s is longer than %d characters", workflow.Name, WorkflowNameMaxLength)
+		}
+		if workflow.ConfigMap != nil && workflow.Git != nil {
+			return kube.NewError(kube.InvalidSpec, "workflow %s must set only one of configMap and git", workflow.Name)
+		}
+		if workflow.ConfigMap == nil && workflow.Git == nil && workflow.Image == "" {
+			return kube.NewError(kube.InvalidSpec, "workflow %s must set one of configMap, git and image", workflow.Name)
+		}
+	}
+	return nil
+}
+
+// getWorkflowDefinition returns the content of the definition of the
+// workflow, in JSON or YAML, or nil for a workflow deployed from its image.
+func getWorkflowDefinition(ctx context.Context, c client.Client, workflow orchestratorv1alpha1.Workflow) ([]byte, error) {
+	if workflow.Git != nil {
+		return gitWorkflowDefinitions.get(ctx, WorkflowDefinitionClient, *workflow.Git)
+	}
+	if workflow.ConfigMap == nil {
+		return nil, nil
+	}
+	source := workflow.ConfigMap
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: SonataFlowNamespace, Name: source.Name}, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, kube.NewError(kube.MissingPrerequisite, "ConfigMap %s/%s of workflow %s not found", SonataFlowNamespace, source.Name, workflow.Name)
+		}
+		return nil, kube.WrapError(kube.TransientAPI, err)
+	}
+	if source.Key != "" {
+		content, found := configMap.Data[source.Key]
+		if !found {
+			return nil, kube.NewError(kube.InvalidSpec, "ConfigMap %s/%s has no key %s for workflow %s", SonataFlowNamespace, source.Name, source.Key, workflow.Name)
+		}
+		return []byte(content), nil
+	}
+	// without a key, the ConfigMap must hold a single definition
+	keys := []string{}
+	for key := range configMap.Data {
+		if strings.HasSuffix(key, ".sw.json") || strings.HasSuffix(key, ".sw.yaml") || strings.HasSuffix(key, ".sw.yml") {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) != 1 {
+		return nil, kube.NewError(kube.InvalidSpec, "ConfigMap %s/%s must hold a single .sw.json or .sw.yaml definition for workflow %s, or set the key",
+			SonataFlowNamespace, source.Name, workflow.Name)
+	}
+	return []byte(configMap.Data[keys[0]]), nil
+}
+
+// gitRawURL returns the URL of the raw content of a file in a GitHub or
+// GitLab repository.
+func gitRawURL(source orchestratorv1alpha1.WorkflowGitSource) (string, error) {
+	repository, err := url.Parse(strings.TrimSuffix(source.Repository, ".git"))
+	if err != nil || repository.Host == "" {
+		return "", kube.NewError(kube.InvalidSpec, "invalid workflow repository %s", source.Repository)
+	}
+	ref := source.Ref
+	if ref == "" {
+		ref = "main"
+	}
+	filePath := strings.TrimPrefix(source.Path, "/")
+	repoPath := strings.Trim(repository.Path, "/")
+	switch {
+	case repository.Host == "github.com":
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repoPath, ref, filePath), nil
+	case strings.Contains(repository.Host, "gitlab"):
+		return fmt.Sprintf("%s://%s/%s/-/raw/%s/%s", repository.Scheme, repository.Host, repoPath, ref, filePath), nil
+	}
+	return "", kube.NewError(kube.InvalidSpec, "workflow repository %s is not on GitHub or GitLab, use a ConfigMap instead", source.Repository)
+}
+
+// gitDefinitionCache keeps the definitions fetched from Git, so they are
+// fetched once per WorkflowDefinitionRefreshPeriod instead of on every
+// reconciliation.
+type gitDefinitionCache struct {
+	mu          sync.Mutex
+	definitions map[string]gitDefinition
+}
+
+type gitDefinition struct {
+	content []byte
+	fetched time.Time
+}
+
+var gitWorkflowDefinitions = &gitDefinitionCache{definitions: map[string]gitDefinition{}}
+
+func (c *gitDefinitionCache) get(ctx context.Context, httpClient *http.Client, source orchestratorv1alpha1.WorkflowGitSource) ([]byte, error) {
+	rawURL, err := gitRawURL(source)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	cached, found := c.definitions[rawURL]
+	c.mu.Unlock()
+	if found && time.Since(cached.fetched) < WorkflowDefinitionRefreshPeriod {
+		return cached.content, nil
+	}
+	content, err := fetchGitWorkflowDefinition(ctx, httpClient, source)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.definitions[rawURL] = gitDefinition{content: content, fetched: time.Now()}
+	c.mu.Unlock()
+	return content, nil
+}
+
+func fetchGitWorkflowDefinition(ctx context.Context, httpClient *http.Client, source orchestratorv1alpha1.WorkflowGitSource) ([]byte, error) {
+	rawURL, err := gitRawURL(source)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, kube.WrapError(kube.TransientAPI, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, kube.NewError(kube.InvalidSpec, "workflow definition %s not found", rawURL)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, kube.NewError(kube.TransientAPI, "fetching workflow definition %s returned %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// getSonataFlow returns the SonataFlow of the workflow. Its persistence uses
+// its own schema of the PostgreSQL database of the platform, when configured.
+// Without a definition, the flow is left to the image of the workflow.
+func getSonataFlow(
+	postgres orchestratorv1alpha1.Postgres,
+	workflow orchestratorv1alpha1.Workflow,
+	definition []byte) (*sonataapi.SonataFlow, error) {
+	sonataFlow := &sonataapi.SonataFlow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: SonataFlowAPIVersion,
+			Kind:       SonataFlowKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        workflow.Name,
+			Namespace:   SonataFlowNamespace,
+			Labels:      kube.AddLabel(),
+			Annotations: map[string]string{sonataFlowProfileAnnotation: sonataFlowPreviewProfile},
+		},
+	}
+	if definition != nil {
+		content, err := yaml.YAMLToJSON(definition)
+		if err != nil {
+			return nil, kube.NewError(kube.InvalidSpec, "invalid definition of workflow %s: %w", workflow.Name, err)
+		}
+		if err := json.Unmarshal(content, &sonataFlow.Spec.Flow); err != nil {
+			return nil, kube.NewError(kube.InvalidSpec, "invalid definition of workflow %s: %w", workflow.Name, err)
+		}
+		metadata := workflowDefinition{}
+		if err := json.Unmarshal(content, &metadata); err != nil {
+			return nil, kube.NewError(kube.InvalidSpec, "invalid definition of workflow %s: %w", workflow.Name, err)
+		}
+		if metadata.Version != "" {
+			sonataFlow.Annotations[sonataFlowVersionAnnotation] = metadata.Version
+		}
+		if metadata.Description != "" {
+			sonataFlow.Annotations[sonataFlowDescriptionAnnotation] = metadata.Description
+		}
+	}
+
+	if workflow.Image != "" {
+		sonataFlow.Annotations[sonataFlowProfileAnnotation] = sonataFlowGitOpsProfile
+		sonataFlow.Spec.PodTemplate.Container.Image = workflow.Image
+	}
+	for _, secret := range workflow.SecretRefs {
+		sonataFlow.Spec.PodTemplate.Container.EnvFrom = append(sonataFlow.Spec.PodTemplate.Container.EnvFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: secret}},
+		})
+	}
+	if postgres.ServiceName != "" {
+		sonataFlow.Spec.Persistence = getWorkflowPersistence(postgres, workflow.Name)
+	}
+	return sonataFlow, nil
+}
+
+// getWorkflowProperties returns the application properties of the workflow,
+// sorted so they don't change between reconciliations.
+func getWorkflowProperties(workflow orchestratorv1alpha1.Workflow) string {
+	keys := make([]string, 0, len(workflow.Properties))
+	for key := range workflow.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	properties := &strings.Builder{}
+	for _, key := range keys {
+		fmt.Fprintf(properties, "%s=%s\n", key, workflow.Properties[key])
+	}
+	return properties.String()
+}
+
+// handleWorkflowProperties creates or updates the properties ConfigMap of the
+// workflow, read by the SonataFlow operator. The ConfigMap is deleted once the
+// properties are removed from the spec.
+func handleWorkflowProperties(ctx context.Context, c client.Client, workflow orchestratorv1alpha1.Workflow) error {
+	logger := log.FromContext(ctx)
+	name := workflow.Name + WorkflowPropertiesSuffix
+	data := map[string]string{WorkflowPropertiesKey: getWorkflowProperties(workflow)}
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: SonataFlowNamespace, Name: name}, configMap)
+	if len(workflow.Properties) == 0 {
+		return deleteWorkflowProperties(ctx, c, configMap, err)
+	}
+	if apierrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: SonataFlowNamespace,
+				Labels:    workflowLabels(workflow.Name),
+			},
+			Data: data,
+		}
+		if err := c.Create(ctx, configMap); err != nil {
+			logger.Error(err, "Error occurred when creating ConfigMap", "CM", name)
+			kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create ConfigMap %s/%s: %v", SonataFlowNamespace, name, err)
+			return err
+		}
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created ConfigMap %s/%s", SonataFlowNamespace, name)
+		return nil
+	}
+	if err != nil {
+		logger.Error(err, "Error occurred when checking ConfigMap exist", "CM", name)
+		return err
+	}
+	// the labels mark a ConfigMap created by the SonataFlow operator as
+	// holding the properties of the spec
+	labels := maps.Clone(configMap.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	maps.Copy(labels, workflowLabels(workflow.Name))
+	if maps.Equal(configMap.Data, data) && maps.Equal(configMap.Labels, labels) {
+		return nil
+	}
+	configMap.Data = data
+	configMap.Labels = labels
+	if err := c.Update(ctx, configMap); err != nil {
+		logger.Error(err, "Error occurred when updating ConfigMap", "CM", name)
+		kube.EventsFromContext(ctx).Warning(kube.ReasonResourceUpdateFailed, "Failed to update ConfigMap %s/%s: %v", SonataFlowNamespace, name, err)
+		return err
+	}
+	kube.EventsFromContext(ctx).Normal(kube.ReasonResourceUpdated, "Updated ConfigMap %s/%s", SonataFlowNamespace, name)
+	return nil
+}
+
+// deleteWorkflowProperties deletes the properties ConfigMap fetched with
+// getErr when it holds the properties of the spec.
+func deleteWorkflowProperties(ctx context.Context, c client.Client, configMap *corev1.ConfigMap, getErr error) error {
+	if apierrors.IsNotFound(getErr) {
+		return nil
+	}
+	if getErr != nil {
+		return getErr
+	}
+	if configMap.Labels[kube.CreatedByLabelKey] != kube.CreatedByLabelValue {
+		return nil
+	}
+	if err := c.Delete(ctx, configMap); err != nil && !apierrors.IsNotFound(err) {
+		log.FromContext(ctx).Error(err, "Error occurred when deleting ConfigMap", "CM", configMap.Name)
+		kube.EventsFromContext(ctx).Warning(kube.ReasonCleanupFailed, "Failed to delete ConfigMap %s/%s: %v", SonataFlowNamespace, configMap.Name, err)
+		return err
+	}
+	kube.EventsFromContext(ctx).Normal(kube.ReasonResourceDeleted, "Deleted ConfigMap %s/%s", SonataFlowNamespace, configMap.Name)
+	return nil
+}
+
+// handleSonataFlow creates or updates the SonataFlow of a workflow.
+func handleSonataFlow(ctx context.Context, c client.Client, desired *sonataapi.SonataFlow) error {
+	logger := log.FromContext(ctx)
+	sonataFlow := &sonataapi.SonataFlow{}
+	err := c.Get(ctx, client.ObjectKeyFromObject(desired), sonataFlow)
+	if apierrors.IsNotFound(err) {
+		if err := c.Create(ctx, desired); err != nil {
+			logger.Error(err, "Error occurred when creating SonataFlow", "Workflow", desired.Name)
+			kube.EventsFromContext(ctx).Warning(kube.ReasonResourceCreationFailed, "Failed to create %s %s/%s: %v",
+				SonataFlowKind, desired.Namespace, desired.Name, err)
+			return err
+		}
+		logger.Info("Successfully created SonataFlow", "Workflow", desired.Name)
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceCreated, "Created %s %s/%s", SonataFlowKind, desired.Namespace, desired.Name)
+		return nil
+	}
+	if err != nil {
+		logger.Error(err, "Error occurred when retrieving SonataFlow", "Workflow", desired.Name)
+		return err
+	}
+
+	annotations := maps.Clone(sonataFlow.Annotations)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	maps.Copy(annotations, desired.Annotations)
+	if equality.Semantic.DeepEqual(sonataFlow.Spec, desired.Spec) && maps.Equal(sonataFlow.Annotations, annotations) {
+		return nil
+	}
+	sonataFlow.Spec = desired.Spec
+	sonataFlow.Annotations = annotations
+	if err := c.Update(ctx, sonataFlow); err != nil {
+		logger.Error(err, "Error occurred when updating SonataFlow", "Workflow", desired.Name)
+		kube.EventsFromContext(ctx).Warning(kube.ReasonResourceUpdateFailed, "Failed to update %s %s/%s: %v",
+			SonataFlowKind, desired.Namespace, desired.Name, err)
+		return err
+	}
+	kube.EventsFromContext(ctx).Normal(kube.ReasonResourceUpdated, "Updated %s %s/%s", SonataFlowKind, desired.Namespace, desired.Name)
+	return nil
+}
+
+// pruneWorkflows deletes the SonataFlows created by the orchestrator that
+// are no longer declared, with their properties, credentials and setup Jobs.
+func pruneWorkflows(ctx context.Context, c client.Client, workflows []orchestratorv1alpha1.Workflow) error {
+	logger := log.FromContext(ctx)
+	declared := map[string]bool{}
+	for _, workflow := range workflows {
+		declared[workflow.Name] = true
+	}
+	sonataFlows := &sonataapi.SonataFlowList{}
+	if err := c.List(ctx, sonataFlows, client.InNamespace(SonataFlowNamespace), client.MatchingLabels(kube.AddLabel())); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	for i := range sonataFlows.Items {
+		sonataFlow := &sonataFlows.Items[i]
+		if declared[sonataFlow.Name] {
+			continue
+		}
+		if err := c.Delete(ctx, sonataFlow); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "Error occurred when deleting SonataFlow", "Workflow", sonataFlow.Name)
+			kube.EventsFromContext(ctx).Warning(kube.ReasonCleanupFailed, "Failed to delete %s %s/%s: %v",
+				SonataFlowKind, sonataFlow.Namespace, sonataFlow.Name, err)
+			return err
+		}
+		kube.EventsFromContext(ctx).Normal(kube.ReasonResourceDeleted, "Deleted %s %s/%s", SonataFlowKind, sonataFlow.Namespace, sonataFlow.Name)
+	}
+	return pruneWorkflowResources(ctx, c, declared)
+}
+
+// workflowsComponent deploys the workflows declared in the spec on the
+// SonataFlow platform.
+type workflowsComponent struct{}
+
+func (c *workflowsComponent) Name() string { return metrics.ComponentWorkflows }
+
+func (c *workflowsComponent) ConditionType() string { return TypeWorkflowsReady }
+
+func (c *workflowsComponent) DependsOn() []string { return []string{metrics.ComponentSonataFlow} }
+
+// Enabled returns whether workflows are declared on an enabled SonataFlow
+// platform. The workflows created before are pruned otherwise.
+func (c *workflowsComponent) Enabled(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.SonataFlowOperator.Enabled && len(spec.Workflows) > 0
+}
+
+func (c *workflowsComponent) Paused(spec orchestratorv1alpha1.OrchestratorSpec) bool {
+	return spec.SonataFlowOperator.Paused
+}
+
+func (c *workflowsComponent) Prerequisites(_ context.Context, _ ComponentEnv, spec orchestratorv1alpha1.OrchestratorSpec) error {
+	return validateWorkflows(spec.Workflows)
+}
+
+func (c *workflowsComponent) Install(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) error {
+	return nil
+}
+
+func (c *workflowsComponent) Reconcile(
+	ctx context.Context,
+	env ComponentEnv,
+	spec orchestratorv1alpha1.OrchestratorSpec) (ComponentResult, error) {
+	if err := checkAPIs(ctx, env, c.Name(), workflowAPIs); err != nil {
+		return ComponentResult{}, err
+	}
+	// a failing workflow doesn't hold back the others, and the workflows
+	// waiting for their database are deployed once it is set up
+	errs := []error{}
+	for _, workflow := range spec.Workflows {
+		if err := reconcileWorkflow(ctx, env.Client, spec.PostgresDB, workflow); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := pruneWorkflows(ctx, env.Client, spec.Workflows); err != nil {
+		errs = append(errs, err)
+	}
+	return ComponentResult{}, joinWorkflowErrors(errs)
+}
+
+// reconcileWorkflow deploys a workflow, once its database is set up.
+func reconcileWorkflow(
+	ctx context.Context,
+	c client.Client,
+	postgres orchestratorv1alpha1.Postgres,
+	workflow orchestratorv1alpha1.Workflow) error {
+	definition, err := getWorkflowDefinition(ctx, c, workflow)
+	if err != nil {
+		return err
+	}
+	sonataFlow, err := getSonataFlow(postgres, workflow, definition)
+	if err != nil {
+		return err
+	}
+	if err := handleWorkflowProperties(ctx, c, workflow); err != nil {
+		return err
+	}
+	if sonataFlow.Spec.Persistence != nil {
+		if err := handleWorkflowDatabase(ctx, c, postgres, workflow.Name); err != nil {
+			return err
+		}
+	}
+	return handleSonataFlow(ctx, c, sonataFlow)
+}
+
+// joinWorkflowErrors joins the errors of the workflows. The reconciliation is
+// retried when any of them is retryable.
+func joinWorkflowErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	kind := kube.InvalidSpec
+	for _, err := range errs {
+		if errKind := kube.ErrorKindOf(err); !errKind.Terminal() {
+			kind = errKind
+			break
+		}
+	}
+	return kube.NewError(kind, "%w", errors.Join(errs...))
+}
+
+func (c *workflowsComponent) Status(context.Context, ComponentEnv, orchestratorv1alpha1.OrchestratorSpec) (ComponentStatus, error) {
+	return ComponentStatus{Phase: metrics.PhaseInstalled}, nil
+}
+
+func (c *workflowsComponent) Cleanup(ctx context.Context, env ComponentEnv, _ orchestratorv1alpha1.OrchestratorSpec) error {
+	return pruneWorkflows(ctx, env.Client, nil)
+}
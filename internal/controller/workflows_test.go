@@ -0,0 +1,330 @@
+/*
+Copyright 2024 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	sonataapi "github.com/apache/incubator-kie-kogito-serverless-operator/api/v1alpha08"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	orchestratorv1alpha1 "github.com/parodos-dev/orchestrator-operator/api/v1alpha1"
+	"github.com/parodos-dev/orchestrator-operator/internal/controller/kube"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const greetingDefinition = `id: greeting
+version: "1.0"
+specVersion: "0.8"
+description: Greets the user
+start: Greet
+states:
+  - name: Greet
+    type: inject
+    data:
+      greeting: Hello
+    end: true
+`
+
+var _ = Describe("Workflows", func() {
+	ctx := context.Background()
+	var env ComponentEnv
+	var orchestrator *orchestratorv1alpha1.Orchestrator
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+		Expect(sonataapi.AddToScheme(scheme)).To(Succeed())
+		definition := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: SonataFlowNamespace, Name: "greeting-workflow"},
+			Data:       map[string]string{"greeting.sw.yaml": greetingDefinition},
+		}
+		// the mapper serves the SonataFlow API checked before deploying
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).
+			WithObjects(definition).
+			Build()
+		env = ComponentEnv{Client: k8sClient}
+
+		orchestrator = &orchestratorv1alpha1.Orchestrator{}
+		orchestrator.Spec.SonataFlowOperator.Enabled = true
+		orchestrator.Spec.PostgresDB = orchestratorv1alpha1.Postgres{
+			ServiceName:      "sonataflow-psql-postgresql",
+			ServiceNameSpace: SonataFlowNamespace,
+			DatabaseName:     "sonataflow",
+			AuthSecret:       orchestratorv1alpha1.PostgresAuthSecret{SecretName: "sonataflow-psql-postgresql", UserKey: "postgres-username", PasswordKey: "postgres-password"},
+		}
+		orchestrator.Spec.Workflows = []orchestratorv1alpha1.Workflow{{
+			Name:       "greeting",
+			ConfigMap:  &orchestratorv1alpha1.WorkflowConfigMapSource{Name: "greeting-workflow"},
+			Properties: map[string]string{"quarkus.log.level": "INFO", "kie.flyway.enabled": "true"},
+			SecretRefs: []string{"greeting-secrets"},
+		}}
+	})
+
+	It("should reject the workflows without a single source", func() {
+		component := &workflowsComponent{}
+		orchestrator.Spec.Workflows[0].Git = &orchestratorv1alpha1.WorkflowGitSource{Repository: "https://github.com/myorg/workflows"}
+		err := component.Prerequisites(ctx, env, orchestrator.Spec)
+		Expect(kube.IsTerminal(err)).To(BeTrue())
+
+		orchestrator.Spec.Workflows[0].Git = nil
+		orchestrator.Spec.Workflows[0].ConfigMap = nil
+		err = component.Prerequisites(ctx, env, orchestrator.Spec)
+		Expect(err).To(MatchError(ContainSubstring("must set one of configMap, git and image")))
+
+		// the image holds the definition
+		orchestrator.Spec.Workflows[0].Image = "quay.io/myorg/greeting:1.0"
+		Expect(component.Prerequisites(ctx, env, orchestrator.Spec)).To(Succeed())
+
+		orchestrator.Spec.Workflows = append(orchestrator.Spec.Workflows, orchestrator.Spec.Workflows[0])
+		Expect(component.Prerequisites(ctx, env, orchestrator.Spec)).To(MatchError(ContainSubstring("declared more than once")))
+
+		// the Job setting up the database is named after the workflow
+		orchestrator.Spec.Workflows = orchestrator.Spec.Workflows[:1]
+		orchestrator.Spec.Workflows[0].Name = strings.Repeat("a", WorkflowNameMaxLength+1)
+		err = component.Prerequisites(ctx, env, orchestrator.Spec)
+		Expect(err).To(MatchError(ContainSubstring("is longer than 54 characters")))
+		Expect(kube.IsTerminal(err)).To(BeTrue())
+	})
+
+	It("should deploy the workflows defined by their image", func() {
+		orchestrator.Spec.PostgresDB = orchestratorv1alpha1.Postgres{}
+		orchestrator.Spec.Workflows[0].ConfigMap = nil
+		orchestrator.Spec.Workflows[0].Image = "quay.io/myorg/greeting:1.0"
+		reconcileWorkflows(ctx, env, orchestrator)
+
+		sonataFlow := &sonataapi.SonataFlow{}
+		Expect(env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting"}, sonataFlow)).To(Succeed())
+		Expect(sonataFlow.Annotations).To(Equal(map[string]string{sonataFlowProfileAnnotation: sonataFlowGitOpsProfile}))
+		Expect(sonataFlow.Spec.PodTemplate.Container.Image).To(Equal("quay.io/myorg/greeting:1.0"))
+		Expect(sonataFlow.Spec.Flow.States).To(BeEmpty())
+	})
+
+	It("should deploy the other workflows when one fails", func() {
+		component := &workflowsComponent{}
+		orchestrator.Spec.PostgresDB = orchestratorv1alpha1.Postgres{}
+		reconcileWorkflows(ctx, env, orchestrator)
+
+		orchestrator.Spec.Workflows = []orchestratorv1alpha1.Workflow{
+			{Name: "missing", ConfigMap: &orchestratorv1alpha1.WorkflowConfigMapSource{Name: "missing-workflow"}},
+			{Name: "hello", ConfigMap: &orchestratorv1alpha1.WorkflowConfigMapSource{Name: "greeting-workflow"}},
+		}
+		_, err := component.Reconcile(ctx, env, orchestrator.Spec)
+		Expect(kube.ErrorKindOf(err)).To(Equal(kube.MissingPrerequisite))
+		Expect(err).To(MatchError(ContainSubstring("missing-workflow")))
+		Expect(env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "hello"}, &sonataapi.SonataFlow{})).To(Succeed())
+		// the workflows no longer declared are still pruned
+		err = env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting"}, &sonataapi.SonataFlow{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should delete the properties removed from the spec", func() {
+		orchestrator.Spec.PostgresDB = orchestratorv1alpha1.Postgres{}
+		reconcileWorkflows(ctx, env, orchestrator)
+		key := client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting" + WorkflowPropertiesSuffix}
+		Expect(env.Get(ctx, key, &corev1.ConfigMap{})).To(Succeed())
+
+		orchestrator.Spec.Workflows[0].Properties = nil
+		reconcileWorkflows(ctx, env, orchestrator)
+		err := env.Get(ctx, key, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		// the properties of the SonataFlow operator are left as is
+		Expect(env.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}})).To(Succeed())
+		reconcileWorkflows(ctx, env, orchestrator)
+		Expect(env.Get(ctx, key, &corev1.ConfigMap{})).To(Succeed())
+	})
+
+	It("should reuse the definitions fetched from Git until they are refreshed", func() {
+		source := orchestratorv1alpha1.WorkflowGitSource{Repository: "https://github.com/myorg/workflows", Path: "greeting.sw.yaml"}
+		rawURL, err := gitRawURL(source)
+		Expect(err).NotTo(HaveOccurred())
+		offline := &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("offline")
+		})}
+		cache := &gitDefinitionCache{definitions: map[string]gitDefinition{
+			rawURL: {content: []byte(greetingDefinition), fetched: time.Now()},
+		}}
+		Expect(cache.get(ctx, offline, source)).To(Equal([]byte(greetingDefinition)))
+
+		cache.definitions[rawURL] = gitDefinition{content: []byte(greetingDefinition), fetched: time.Now().Add(-WorkflowDefinitionRefreshPeriod)}
+		_, err = cache.get(ctx, offline, source)
+		Expect(err).To(MatchError(ContainSubstring("offline")))
+	})
+
+	It("should deploy the declared workflows as SonataFlows", func() {
+		component := &workflowsComponent{}
+		reconcileWorkflows(ctx, env, orchestrator)
+
+		sonataFlow := &sonataapi.SonataFlow{}
+		Expect(env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting"}, sonataFlow)).To(Succeed())
+		Expect(sonataFlow.Labels).To(Equal(kube.AddLabel()))
+		Expect(sonataFlow.Annotations).To(Equal(map[string]string{
+			sonataFlowProfileAnnotation:     sonataFlowPreviewProfile,
+			sonataFlowVersionAnnotation:     "1.0",
+			sonataFlowDescriptionAnnotation: "Greets the user",
+		}))
+		Expect(sonataFlow.Spec.Flow.States).To(HaveLen(1))
+		Expect(sonataFlow.Spec.PodTemplate.Container.EnvFrom).To(ConsistOf(HaveField("SecretRef.Name", "greeting-secrets")))
+		Expect(sonataFlow.Spec.Persistence.PostgreSQL.ServiceRef.Name).To(Equal("sonataflow-psql-postgresql"))
+		Expect(sonataFlow.Spec.Persistence.PostgreSQL.ServiceRef.DatabaseName).To(Equal("sonataflow"))
+		Expect(sonataFlow.Spec.Persistence.PostgreSQL.ServiceRef.DatabaseSchema).To(Equal("wf_greeting"))
+		Expect(sonataFlow.Spec.Persistence.PostgreSQL.SecretRef.Name).To(Equal("greeting" + WorkflowCredentialsSuffix))
+
+		properties := &corev1.ConfigMap{}
+		Expect(env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting" + WorkflowPropertiesSuffix}, properties)).To(Succeed())
+		Expect(properties.Data).To(HaveKeyWithValue(WorkflowPropertiesKey, "kie.flyway.enabled=true\nquarkus.log.level=INFO\n"))
+
+		// an image switches the workflow to the gitops profile
+		orchestrator.Spec.Workflows[0].Image = "quay.io/myorg/greeting:1.0"
+		Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+		Expect(env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting"}, sonataFlow)).To(Succeed())
+		Expect(sonataFlow.Annotations).To(HaveKeyWithValue(sonataFlowProfileAnnotation, sonataFlowGitOpsProfile))
+		Expect(sonataFlow.Spec.PodTemplate.Container.Image).To(Equal("quay.io/myorg/greeting:1.0"))
+	})
+
+	It("should set up a database schema and credentials per workflow", func() {
+		component := &workflowsComponent{}
+		_, err := component.Reconcile(ctx, env, orchestrator.Spec)
+		var notReady *workflowDatabaseNotReadyError
+		Expect(errors.As(err, &notReady)).To(BeTrue())
+		Expect(kube.ErrorKindOf(err)).To(Equal(kube.MissingPrerequisite))
+		// the workflow is deployed once its schema is set up
+		err = env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting"}, &sonataapi.SonataFlow{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		credentials := &corev1.Secret{}
+		Expect(env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting" + WorkflowCredentialsSuffix}, credentials)).To(Succeed())
+		Expect(credentials.StringData).To(HaveKeyWithValue(WorkflowDatabaseUserKey, "wf_greeting"))
+		Expect(credentials.StringData[WorkflowDatabasePasswordKey]).To(HaveLen(48))
+
+		job := &batchv1.Job{}
+		Expect(env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting" + WorkflowDatabaseSetupSuffix}, job)).To(Succeed())
+		container := job.Spec.Template.Spec.Containers[0]
+		Expect(container.Image).To(Equal(PostgresClientImage))
+		Expect(container.Env).To(ContainElements(
+			corev1.EnvVar{Name: "PGHOST", Value: "sonataflow-psql-postgresql.sonataflow-infra.svc"},
+			corev1.EnvVar{Name: "PGDATABASE", Value: "sonataflow"},
+			corev1.EnvVar{Name: "WORKFLOW_SCHEMA", Value: "wf_greeting"},
+			HaveField("ValueFrom.SecretKeyRef.Key", "postgres-password"),
+		))
+		// the roles are prefixed, and the roles not created by the operator are refused
+		Expect(workflowDatabaseSchema("postgres")).To(Equal("wf_postgres"))
+		Expect(container.Env).To(ContainElement(And(
+			HaveField("Name", "SETUP_SQL"),
+			HaveField("Value", ContainSubstring("was not created by the orchestrator operator")),
+		)))
+
+		// the password is read from the environment, not from the arguments of psql
+		Expect(container.Command[2]).NotTo(ContainSubstring("WORKFLOW_PASSWORD"))
+		Expect(container.Env).To(ContainElement(And(
+			HaveField("Name", "SETUP_SQL"),
+			HaveField("Value", HavePrefix(`\getenv user WORKFLOW_USER`)),
+		)))
+
+		// a failed Job is not retried until the spec changes, and is deleted to run again
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+		Expect(env.Status().Update(ctx, job)).To(Succeed())
+		_, err = component.Reconcile(ctx, env, orchestrator.Spec)
+		Expect(kube.IsTerminal(err)).To(BeTrue())
+		err = env.Get(ctx, client.ObjectKeyFromObject(job), &batchv1.Job{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		// the credentials are kept
+		reconcileWorkflows(ctx, env, orchestrator)
+		kept := &corev1.Secret{}
+		Expect(env.Get(ctx, client.ObjectKeyFromObject(credentials), kept)).To(Succeed())
+		Expect(kept.StringData).To(Equal(credentials.StringData))
+	})
+
+	It("should prune the workflows no longer declared", func() {
+		component := &workflowsComponent{}
+		reconcileWorkflows(ctx, env, orchestrator)
+		unmanaged := &sonataapi.SonataFlow{ObjectMeta: metav1.ObjectMeta{Namespace: SonataFlowNamespace, Name: "unmanaged"}}
+		Expect(env.Create(ctx, unmanaged)).To(Succeed())
+
+		Expect(component.Cleanup(ctx, env, orchestrator.Spec)).To(Succeed())
+		err := env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting"}, &sonataapi.SonataFlow{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		err = env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting" + WorkflowPropertiesSuffix}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		err = env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting" + WorkflowCredentialsSuffix}, &corev1.Secret{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		err = env.Get(ctx, client.ObjectKey{Namespace: SonataFlowNamespace, Name: "greeting" + WorkflowDatabaseSetupSuffix}, &batchv1.Job{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		// the SonataFlows not created by the orchestrator are left as is
+		Expect(env.Get(ctx, client.ObjectKeyFromObject(unmanaged), &sonataapi.SonataFlow{})).To(Succeed())
+	})
+
+	It("should map the Git sources to their raw content", func() {
+		rawURL, err := gitRawURL(orchestratorv1alpha1.WorkflowGitSource{
+			Repository: "https://github.com/myorg/workflows.git",
+			Path:       "/greeting/greeting.sw.yaml",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rawURL).To(Equal("https://raw.githubusercontent.com/myorg/workflows/main/greeting/greeting.sw.yaml"))
+
+		rawURL, err = gitRawURL(orchestratorv1alpha1.WorkflowGitSource{
+			Repository: "https://gitlab.example.com/myorg/workflows",
+			Ref:        "v1.0",
+			Path:       "greeting/greeting.sw.yaml",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rawURL).To(Equal("https://gitlab.example.com/myorg/workflows/-/raw/v1.0/greeting/greeting.sw.yaml"))
+
+		_, err = gitRawURL(orchestratorv1alpha1.WorkflowGitSource{Repository: "https://bitbucket.org/myorg/workflows"})
+		Expect(kube.IsTerminal(err)).To(BeTrue())
+	})
+})
+
+// reconcileWorkflows reconciles the workflows, completing the Jobs setting up
+// their database as they are created.
+func reconcileWorkflows(ctx context.Context, env ComponentEnv, orchestrator *orchestratorv1alpha1.Orchestrator) {
+	component := &workflowsComponent{}
+	_, err := component.Reconcile(ctx, env, orchestrator.Spec)
+	var notReady *workflowDatabaseNotReadyError
+	if !errors.As(err, &notReady) {
+		Expect(err).NotTo(HaveOccurred())
+		return
+	}
+	jobs := &batchv1.JobList{}
+	Expect(env.List(ctx, jobs, client.InNamespace(SonataFlowNamespace))).To(Succeed())
+	for i := range jobs.Items {
+		jobs.Items[i].Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		Expect(env.Status().Update(ctx, &jobs.Items[i])).To(Succeed())
+	}
+	Expect(component.Reconcile(ctx, env, orchestrator.Spec)).Error().To(Succeed())
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}